@@ -2,9 +2,11 @@ package main
 
 import (
 	"encoding/json"
+	"flag"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/AI-S-Tools/code-bridge/pkg/indexer"
@@ -26,13 +28,19 @@ func main() {
 	case "init":
 		cmdInit()
 	case "index":
-		cmdIndex()
+		cmdIndex(os.Args[2:])
 	case "search":
 		if len(os.Args) < 3 {
 			fmt.Println("Usage: code-bridge search <query>")
 			os.Exit(1)
 		}
 		cmdSearch(os.Args[2])
+	case "callers", "callees":
+		if len(os.Args) < 3 {
+			fmt.Printf("Usage: code-bridge %s <symbol>\n", command)
+			os.Exit(1)
+		}
+		cmdCallGraph(command, os.Args[2])
 	case "stats":
 		cmdStats()
 	case "rebuild":
@@ -53,7 +61,10 @@ func printUsage() {
 	fmt.Println("\nUsage:")
 	fmt.Println("  code-bridge init         Initialize code-bridge in current directory")
 	fmt.Println("  code-bridge index        Index the codebase")
+	fmt.Println("      -goos, -goarch, -tags   Only index files built for this target")
 	fmt.Println("  code-bridge search <q>   Search for code elements")
+	fmt.Println("  code-bridge callers <s>  Show the functions calling a symbol")
+	fmt.Println("  code-bridge callees <s>  Show the functions a symbol calls")
 	fmt.Println("  code-bridge rag          List all indexed code elements (RAG format)")
 	fmt.Println("  code-bridge stats        Show index statistics")
 	fmt.Println("  code-bridge rebuild      Rebuild the index")
@@ -70,10 +81,11 @@ func cmdInit() {
 	}
 
 	config := map[string]interface{}{
-		"root":     cwd,
-		"include":  []string{"*.go", "*.js", "*.ts", "*.py"},
-		"exclude":  []string{"node_modules", ".git", "dist", "vendor"},
-		"languages": []string{"go", "javascript", "typescript"},
+		"root":      cwd,
+		"include":   []string{"*.go", "*.js", "*.ts", "*.py"},
+		"exclude":   []string{"node_modules", ".git", "dist", "vendor"},
+		"languages": newRegistry(nil).Languages(),
+		"profiles":  parser.DefaultProfiles(),
 	}
 
 	configPath := filepath.Join(configDir, "config.json")
@@ -88,13 +100,65 @@ func cmdInit() {
 	fmt.Printf("  Index: %s/codebase.jsonl\n", configDir)
 }
 
-func cmdIndex() {
+// config is the part of .code-bridge/config.json the commands read
+type config struct {
+	Languages []string         `json:"languages"` // Languages to index; all when absent
+	Profiles  []parser.Profile `json:"profiles"`  // Regex parser languages; the defaults when absent
+}
+
+// newRegistry returns the parsers of the languages cfg (when not nil)
+// selects: the dedicated ones, then the regex parser profiles
+func newRegistry(cfg *config) *parser.Registry {
+	registry := parser.DefaultRegistry()
+	profiles := parser.DefaultProfiles()
+	if cfg != nil && cfg.Profiles != nil {
+		profiles = cfg.Profiles
+	}
+	for _, profile := range profiles {
+		if err := registry.RegisterProfile(profile); err != nil {
+			fmt.Printf("Warning: %v\n", err)
+		}
+	}
+	if cfg != nil && cfg.Languages != nil {
+		registry.SetLanguages(cfg.Languages)
+	}
+	return registry
+}
+
+// readConfig reads the config written by init
+func readConfig(configDir string) (*config, error) {
+	data, err := os.ReadFile(filepath.Join(configDir, "config.json"))
+	if err != nil {
+		return nil, err
+	}
+	var cfg config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+	return &cfg, nil
+}
+
+func cmdIndex(args []string) {
+	flags := flag.NewFlagSet("index", flag.ExitOnError)
+	goos := flags.String("goos", "", "only index Go files built for this GOOS")
+	goarch := flags.String("goarch", "", "only index Go files built for this GOARCH")
+	tags := flags.String("tags", "", "comma-separated build tags for -goos/-goarch")
+	flags.Parse(args)
+
 	cwd, _ := os.Getwd()
 	configDir := filepath.Join(cwd, ".code-bridge")
 	indexPath := filepath.Join(configDir, "codebase.jsonl")
 
+	cfg, err := readConfig(configDir)
+	if err != nil && !os.IsNotExist(err) {
+		fmt.Printf("Warning: %v\n", err)
+	}
+	registry := newRegistry(cfg)
+
 	fmt.Println("Scanning files...")
 	s := scanner.New(cwd)
+	s.SetIncludePatterns(registry.Patterns())
+	s.SetIncludeScripts(registry.HasShebangs())
 	s.LoadGitignore()
 	files, err := s.Scan()
 	if err != nil {
@@ -102,9 +166,57 @@ func cmdIndex() {
 		os.Exit(1)
 	}
 
-	fmt.Printf("Found %d files\n", len(files))
+	// Go is type-checked a package (directory) at a time
+	goDirs := make([]string, 0)
+	goFilesPerDir := make(map[string]int)
+	foreignFilesPerDir := make(map[string]int)
+	otherFiles := make([]scanner.ScannedFile, 0)
+	otherParsers := make(map[string]parser.Parser)
+	for _, file := range files {
+		lang := registry.Lookup(file.Path)
+		if lang == nil {
+			continue
+		}
+		if lang.Name != "go" {
+			// Other languages are parsed a file at a time
+			otherFiles = append(otherFiles, file)
+			otherParsers[file.Path] = lang.Parser
+			continue
+		}
+
+		dir := filepath.Dir(file.Path)
+		if filepath.Ext(file.Path) != ".go" {
+			// Assembly and cgo sources are loaded with their Go package;
+			// outside a Go package they are not Go's
+			foreignFilesPerDir[dir]++
+			continue
+		}
+		if goFilesPerDir[dir] == 0 {
+			goDirs = append(goDirs, dir)
+		}
+		goFilesPerDir[dir]++
+	}
+
+	// Only files a language resolved count: not every extensionless file
+	// (LICENSE, Dockerfile), nor C and assembly outside a Go package
+	found := len(otherFiles)
+	for dir, n := range goFilesPerDir {
+		found += n + foreignFilesPerDir[dir]
+	}
+	fmt.Printf("Found %d files\n", found)
 
-	goParser := parser.NewGoParser()
+	goLoader := parser.NewGoLoader(cwd, s.Excludes)
+	if *goos != "" || *goarch != "" || *tags != "" {
+		target := &parser.BuildTarget{GOOS: *goos, GOARCH: *goarch}
+		if *tags != "" {
+			target.Tags = strings.Split(*tags, ",")
+		}
+		goLoader.SetBuildTarget(target)
+		fmt.Println("Indexing Go for a single build target; other targets' variants are left out")
+	}
+	if modules := goLoader.Modules(); len(modules) > 1 {
+		fmt.Printf("Found %d Go modules\n", len(modules))
+	}
 	idx := indexer.New(indexPath, true)
 
 	if err := idx.Init(); err != nil {
@@ -112,43 +224,91 @@ func cmdIndex() {
 		os.Exit(1)
 	}
 
-	totalElements := 0
 	totalFiles := 0
 
 	fmt.Println("Parsing and indexing...")
-	for _, file := range files {
-		if !goParser.SupportsFile(file.Path) {
+	elements := make([]parser.CodeElement, 0)
+	for _, dir := range goDirs {
+		relDir, _ := filepath.Rel(cwd, dir)
+
+		result, err := goLoader.LoadDir(dir)
+		if err != nil {
+			fmt.Printf("  Warning: cannot load package %s\n", relDir)
 			continue
 		}
 
+		for _, parseErr := range result.Errors {
+			fmt.Printf("\n  Warning: %v", parseErr)
+		}
+		if len(result.Errors) > 0 {
+			fmt.Println()
+		}
+
+		elements = append(elements, result.Elements...)
+		totalFiles += result.Files
+
+		fmt.Printf("\r  Processed: %d files, %d elements", totalFiles, len(elements))
+	}
+
+	// Batch parsers (SQL migrations) get all their files together afterwards
+	batchParsers := make([]parser.BatchParser, 0)
+	batches := make(map[parser.BatchParser][]parser.SourceFile)
+	for _, file := range otherFiles {
+		p := otherParsers[file.Path]
 		content, err := os.ReadFile(file.Path)
 		if err != nil {
-			fmt.Printf("  Warning: cannot read %s\n", file.RelativePath)
+			fmt.Printf("\n  Warning: cannot read %s: %v\n", file.RelativePath, err)
 			continue
 		}
-
-		result, err := goParser.Parse(file.RelativePath, content)
+		if bp, ok := p.(parser.BatchParser); ok {
+			if _, seen := batches[bp]; !seen {
+				batchParsers = append(batchParsers, bp)
+			}
+			batches[bp] = append(batches[bp], parser.SourceFile{Path: file.RelativePath, Content: content})
+			continue
+		}
+		result, err := p.Parse(file.RelativePath, content)
 		if err != nil {
-			fmt.Printf("  Warning: cannot parse %s\n", file.RelativePath)
+			fmt.Printf("\n  Warning: cannot parse %s: %v\n", file.RelativePath, err)
 			continue
 		}
-
-		if len(result.Errors) > 0 {
-			fmt.Printf("  Warning: %s has parse errors\n", file.RelativePath)
+		for _, parseErr := range result.Errors {
+			fmt.Printf("\n  Warning: %v\n", parseErr)
 		}
 
-		indexed, err := idx.Index(result.Elements)
+		elements = append(elements, result.Elements...)
+		totalFiles++
+
+		fmt.Printf("\r  Processed: %d files, %d elements", totalFiles, len(elements))
+	}
+
+	for _, bp := range batchParsers {
+		result, err := bp.ParseAll(batches[bp])
 		if err != nil {
-			fmt.Printf("  Error indexing %s: %v\n", file.RelativePath, err)
+			fmt.Printf("\n  Warning: %v\n", err)
 			continue
 		}
+		for _, parseErr := range result.Errors {
+			fmt.Printf("\n  Warning: %v\n", parseErr)
+		}
 
-		totalElements += indexed
-		totalFiles++
+		elements = append(elements, result.Elements...)
+		totalFiles += len(batches[bp])
 
-		if totalFiles%10 == 0 {
-			fmt.Printf("\r  Processed: %d files, %d elements", totalFiles, totalElements)
-		}
+		fmt.Printf("\r  Processed: %d files, %d elements", totalFiles, len(elements))
+	}
+
+	// Methods, Implements and the links between elements need every file parsed first
+	elements = parser.MergePackages(elements)
+	goLoader.Link(elements)
+	parser.Link(elements)
+
+	// Every file was parsed again, so the index is replaced rather than
+	// appended to: the links changed on elements whose bodies did not
+	totalElements, err := idx.Replace(elements)
+	if err != nil {
+		fmt.Printf("\nError: %v\n", err)
+		os.Exit(1)
 	}
 
 	fmt.Printf("\n✓ Indexing complete\n")
@@ -164,8 +324,19 @@ func cmdSearch(query string) {
 
 	results, err := idx.Search(func(el parser.CodeElement) bool {
 		lowerQuery := strings.ToLower(query)
-		return strings.Contains(strings.ToLower(el.Name), lowerQuery) ||
-			strings.Contains(strings.ToLower(el.Body), lowerQuery)
+		if strings.Contains(strings.ToLower(el.Name), lowerQuery) ||
+			strings.Contains(strings.ToLower(el.ID), lowerQuery) ||
+			strings.Contains(strings.ToLower(el.Body), lowerQuery) {
+			return true
+		}
+		// "parser.Parser" (or just "Parser") finds the types implementing it
+		for _, iface := range el.Implements {
+			iface = strings.ToLower(iface)
+			if iface == lowerQuery || strings.HasSuffix(iface, "."+lowerQuery) {
+				return true
+			}
+		}
+		return false
 	})
 
 	if err != nil {
@@ -187,6 +358,21 @@ func cmdSearch(query string) {
 	for _, result := range results {
 		fmt.Printf("  %s %s\n", result.Type, result.Name)
 		fmt.Printf("    %s:%d\n", result.File, result.Line)
+		if result.ID != "" {
+			fmt.Printf("    ID: %s\n", result.ID)
+		}
+		if result.Module != "" {
+			fmt.Printf("    Module: %s\n", result.Module)
+		}
+		if result.BuildConstraint != "" {
+			fmt.Printf("    Build: %s\n", result.BuildConstraint)
+		}
+		if result.Context != "" {
+			fmt.Printf("    In: %s\n", result.Context)
+		}
+		if len(result.Decorators) > 0 {
+			fmt.Printf("    Decorators: @%s\n", strings.Join(result.Decorators, ", @"))
+		}
 		if len(result.Params) > 0 {
 			params := make([]string, len(result.Params))
 			for i, p := range result.Params {
@@ -201,10 +387,128 @@ func cmdSearch(query string) {
 		if result.Returns != "" {
 			fmt.Printf("    Returns: %s\n", result.Returns)
 		}
+		if m := result.Metrics; m != nil {
+			fmt.Printf("    Complexity: cognitive %d, cyclomatic %d, nesting %d, %d lines\n",
+				m.Cognitive, m.Cyclomatic, m.MaxNesting, m.Lines)
+		}
+		if len(result.Fields) > 0 {
+			fmt.Println("    Fields:")
+			for _, field := range result.Fields {
+				fmt.Printf("      %s\n", field)
+			}
+		}
+		if result.Value != "" {
+			fmt.Printf("    Value: %s\n", result.Value)
+		}
+		if result.EnumOf != "" {
+			fmt.Printf("    Enum of: %s\n", result.EnumOf)
+		}
+		if len(result.EnumValues) > 0 {
+			fmt.Printf("    Values: %s\n", strings.Join(result.EnumValues, ", "))
+		}
+		if len(result.Prerequisites) > 0 {
+			fmt.Printf("    Prerequisites: %s\n", strings.Join(result.Prerequisites, " "))
+		}
+		if result.Type == parser.TypeTarget {
+			fmt.Println("    Recipe:")
+			for _, line := range strings.Split(result.Body, "\n") {
+				if strings.HasPrefix(line, "\t") {
+					fmt.Printf("      %s\n", strings.TrimSpace(line))
+				}
+			}
+		}
+		if len(result.Methods) > 0 {
+			fmt.Printf("    Methods: %s\n", strings.Join(result.Methods, ", "))
+		}
+		if len(result.Implements) > 0 {
+			fmt.Printf("    Implements: %s\n", strings.Join(result.Implements, ", "))
+		}
+		if result.Subject != "" {
+			fmt.Printf("    Tests: %s\n", result.Subject)
+		}
+		if len(result.TestedBy) > 0 {
+			fmt.Printf("    Tested by: %s\n", strings.Join(result.TestedBy, ", "))
+		}
+		if len(result.Examples) > 0 {
+			fmt.Printf("    Examples: %s\n", strings.Join(result.Examples, ", "))
+		}
+		if len(result.ImplementedBy) > 0 {
+			fmt.Printf("    Implemented by: %s\n", strings.Join(result.ImplementedBy, ", "))
+		}
+		if result.Declaration != "" {
+			fmt.Printf("    Declared as: %s\n", result.Declaration)
+		}
+		if len(result.Documents) > 0 {
+			fmt.Printf("    Documents: %s\n", strings.Join(result.Documents, ", "))
+		}
+		if len(result.DocumentedIn) > 0 {
+			fmt.Printf("    Documented in: %s\n", strings.Join(result.DocumentedIn, ", "))
+		}
 		fmt.Println()
 	}
 }
 
+func cmdCallGraph(direction, symbol string) {
+	cwd, _ := os.Getwd()
+	indexPath := filepath.Join(cwd, ".code-bridge", "codebase.jsonl")
+
+	idx := indexer.New(indexPath, true)
+	elements, err := idx.ReadAll()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	functions := make(map[string]parser.CodeElement)
+	matches := make([]parser.CodeElement, 0)
+	for _, el := range elements {
+		if !el.Type.IsFunction() {
+			continue
+		}
+		functions[el.ID] = el
+
+		if matchesSymbol(el, symbol) {
+			matches = append(matches, el)
+		}
+	}
+
+	if len(matches) == 0 {
+		fmt.Println("No results found")
+		return
+	}
+
+	for _, el := range matches {
+		related := el.Calls
+		if direction == "callers" {
+			related = el.CalledBy
+		}
+
+		fmt.Printf("  %s %s\n", el.Type, el.ID)
+		fmt.Printf("    %s:%d\n", el.File, el.Line)
+		if len(related) == 0 {
+			fmt.Printf("    (no %s)\n\n", direction)
+			continue
+		}
+
+		fmt.Printf("    %s:\n", strings.ToUpper(direction[:1])+direction[1:])
+		for _, name := range related {
+			if fn, ok := functions[name]; ok {
+				fmt.Printf("      %s - %s:%d\n", fn.Name, fn.File, fn.Line)
+			} else {
+				fmt.Printf("      %s (not indexed)\n", name)
+			}
+		}
+		fmt.Println()
+	}
+}
+
+// matchesSymbol accepts a name ("Search", "Indexer.Search") or a symbol ID,
+// full or from any path segment on ("indexer.(*Indexer).Search")
+func matchesSymbol(el parser.CodeElement, symbol string) bool {
+	return el.Name == symbol || strings.HasSuffix(el.Name, "."+symbol) ||
+		el.ID == symbol || strings.HasSuffix(el.ID, "/"+symbol)
+}
+
 func cmdStats() {
 	cwd, _ := os.Getwd()
 	indexPath := filepath.Join(cwd, ".code-bridge", "codebase.jsonl")
@@ -216,7 +520,8 @@ func cmdStats() {
 		os.Exit(1)
 	}
 
-	fmt.Println("Code-bridge Statistics\n")
+	fmt.Println("Code-bridge Statistics")
+	fmt.Println()
 	fmt.Printf("Total Elements: %d\n", stats.TotalElements)
 	fmt.Printf("Total Size: %.2f KB\n\n", float64(stats.TotalSize)/1024)
 
@@ -230,6 +535,18 @@ func cmdStats() {
 		fmt.Printf("  %s: %d\n", lang, count)
 	}
 
+	if len(stats.ByModule) > 1 {
+		fmt.Println("\nBy Module:")
+		modules := make([]string, 0, len(stats.ByModule))
+		for module := range stats.ByModule {
+			modules = append(modules, module)
+		}
+		sort.Strings(modules)
+		for _, module := range modules {
+			fmt.Printf("  %s: %d\n", module, stats.ByModule[module])
+		}
+	}
+
 	fmt.Println("\nTop Files:")
 	// Sort and show top 10 files
 	type fileStat struct {
@@ -258,6 +575,25 @@ func cmdStats() {
 	for i := 0; i < limit; i++ {
 		fmt.Printf("  %s: %d elements\n", fileStats[i].file, fileStats[i].count)
 	}
+
+	if len(stats.Hotspots) > 0 {
+		fmt.Println("\nHotspots (by cognitive complexity):")
+		packages := make([]string, 0, len(stats.Hotspots))
+		for pkg := range stats.Hotspots {
+			packages = append(packages, pkg)
+		}
+		sort.Strings(packages)
+
+		for _, pkg := range packages {
+			fmt.Printf("  %s\n", pkg)
+			for _, h := range stats.Hotspots[pkg] {
+				m := h.Metrics
+				fmt.Printf("    %s - %s:%d\n", h.Name, h.File, h.Line)
+				fmt.Printf("      cognitive %d, cyclomatic %d, nesting %d, %d statements, %d params, %d lines\n",
+					m.Cognitive, m.Cyclomatic, m.MaxNesting, m.Statements, m.Params, m.Lines)
+			}
+		}
+	}
 }
 
 func cmdRebuild() {
@@ -307,6 +643,8 @@ func cmdRAG() {
 		output = indexer.FormatRAGByFile(ragOutput)
 	case "type":
 		output = indexer.FormatRAGByType(ragOutput)
+	case "package":
+		output = indexer.FormatRAGByPackage(ragOutput)
 	case "compact":
 		fallthrough
 	default:
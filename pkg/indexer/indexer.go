@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"sort"
 	"sync"
 
 	"github.com/AI-S-Tools/code-bridge/pkg/parser"
@@ -66,6 +67,19 @@ func (idx *Indexer) Index(elements []parser.CodeElement) (int, error) {
 	return len(toWrite), nil
 }
 
+// Replace replaces the index with elements. A full index run uses it: the
+// reverse links (CalledBy, TestedBy, ...) are computed on the whole set, and
+// appending would keep the stale copies of the elements they changed.
+func (idx *Indexer) Replace(elements []parser.CodeElement) (int, error) {
+	if err := idx.Clear(); err != nil {
+		return 0, err
+	}
+	if err := idx.Init(); err != nil {
+		return 0, err
+	}
+	return idx.Index(elements)
+}
+
 // appendToIndex appends elements to JSONL file
 func (idx *Indexer) appendToIndex(elements []parser.CodeElement) error {
 	file, err := os.OpenFile(idx.indexPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
@@ -169,10 +183,24 @@ type Stats struct {
 	TotalElements int
 	ByType        map[parser.ElementType]int
 	ByLanguage    map[string]int
+	ByModule      map[string]int // Elements per Go module
 	ByFile        map[string]int
 	TotalSize     int64
+	Hotspots      map[string][]Hotspot // Most complex functions per package
+}
+
+// Hotspot is a function worth refactoring first
+type Hotspot struct {
+	ID      string
+	Name    string
+	File    string
+	Line    int
+	Metrics parser.Metrics
 }
 
+// hotspotsPerPackage is how many of the worst functions each package lists
+const hotspotsPerPackage = 3
+
 // GetStats returns index statistics
 func (idx *Indexer) GetStats() (*Stats, error) {
 	elements, err := idx.ReadAll()
@@ -184,15 +212,48 @@ func (idx *Indexer) GetStats() (*Stats, error) {
 		TotalElements: len(elements),
 		ByType:        make(map[parser.ElementType]int),
 		ByLanguage:    make(map[string]int),
+		ByModule:      make(map[string]int),
 		ByFile:        make(map[string]int),
 		TotalSize:     0,
+		Hotspots:      make(map[string][]Hotspot),
 	}
 
 	for _, el := range elements {
 		stats.ByType[el.Type]++
 		stats.ByLanguage[el.Language]++
+		if el.Module != "" {
+			stats.ByModule[el.Module]++
+		}
 		stats.ByFile[el.File]++
 		stats.TotalSize += int64(len(el.Body))
+
+		// Straight-line functions are never hotspots
+		if el.Metrics != nil && el.Metrics.Cognitive > 0 {
+			pkg := el.ImportPath
+			if pkg == "" {
+				pkg = filepath.Dir(el.File)
+			}
+			stats.Hotspots[pkg] = append(stats.Hotspots[pkg], Hotspot{
+				ID:      el.ID,
+				Name:    el.Name,
+				File:    el.File,
+				Line:    el.Line,
+				Metrics: *el.Metrics,
+			})
+		}
+	}
+
+	for pkg, hotspots := range stats.Hotspots {
+		sort.Slice(hotspots, func(i, j int) bool {
+			a, b := hotspots[i].Metrics, hotspots[j].Metrics
+			if a.Cognitive != b.Cognitive {
+				return a.Cognitive > b.Cognitive
+			}
+			return a.Cyclomatic > b.Cyclomatic
+		})
+		if len(hotspots) > hotspotsPerPackage {
+			stats.Hotspots[pkg] = hotspots[:hotspotsPerPackage]
+		}
 	}
 
 	return stats, nil
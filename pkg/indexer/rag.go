@@ -2,6 +2,7 @@ package indexer
 
 import (
 	"fmt"
+	"path"
 	"sort"
 	"strings"
 
@@ -14,16 +15,32 @@ type RAGOutput struct {
 	TotalElements int
 	ByFile        map[string][]RAGElement
 	ByType        map[parser.ElementType][]RAGElement
+	ByPackage     map[string][]RAGElement
 }
 
 // RAGElement is a simplified element for RAG output
 type RAGElement struct {
 	Type       parser.ElementType
 	Name       string
+	ID         string
+	ImportPath string
 	File       string
 	Line       int
 	Signature  string
 	Docstring  string
+	Methods    []string
+	Implements []string
+	EnumValues []string
+	Fields     []parser.Field
+	Exports    bool
+	Build      string
+	TestedBy   []string
+	Examples   []string // Source of runnable examples
+	Docs       []string // Text of the documentation sections mentioning the element
+
+	Decorators    []string
+	ImplementedBy []string // Assembly behind a bodiless declaration, Go generated from a proto definition, handlers of an endpoint
+	Declaration   string   // Declaration an assembly function, generated Go code or handler implements
 }
 
 // GetRAGIndex returns organized code elements for RAG/LLM consumption
@@ -37,21 +54,60 @@ func (idx *Indexer) GetRAGIndex(groupBy string) (*RAGOutput, error) {
 		TotalElements: len(elements),
 		ByFile:        make(map[string][]RAGElement),
 		ByType:        make(map[parser.ElementType][]RAGElement),
+		ByPackage:     make(map[string][]RAGElement),
+	}
+
+	// Examples and documentation are shown with the API they document
+	exampleBodies := make(map[string]string)
+	sectionBodies := make(map[string]string)
+	for _, el := range elements {
+		switch el.Type {
+		case parser.TypeExample:
+			exampleBodies[el.ID] = el.Body
+		case parser.TypeSection:
+			sectionBodies[el.ID] = el.Body
+		}
 	}
 
 	// Convert to RAG elements and organize
 	for _, el := range elements {
 		ragEl := RAGElement{
-			Type:      el.Type,
-			Name:      el.Name,
-			File:      el.File,
-			Line:      el.Line,
-			Signature: buildSignature(el),
-			Docstring: el.Docstring,
+			Type:       el.Type,
+			Name:       el.Name,
+			ID:         el.ID,
+			ImportPath: el.ImportPath,
+			File:       el.File,
+			Line:       el.Line,
+			Signature:  buildSignature(el),
+			Docstring:  el.Docstring,
+			Implements: el.Implements,
+			EnumValues: el.EnumValues,
+			Fields:     el.Fields,
+			Exports:    el.Exports,
+			Build:      el.BuildConstraint,
+			TestedBy:   el.TestedBy,
+
+			Decorators:    el.Decorators,
+			ImplementedBy: el.ImplementedBy,
+			Declaration:   el.Declaration,
+		}
+		if !el.Type.IsFunction() {
+			ragEl.Methods = el.Methods
+		}
+		for _, example := range el.Examples {
+			if body, ok := exampleBodies[example]; ok {
+				ragEl.Examples = append(ragEl.Examples, body)
+			}
+		}
+		for _, section := range el.DocumentedIn {
+			if body, ok := sectionBodies[section]; ok {
+				ragEl.Docs = append(ragEl.Docs, body)
+			}
 		}
 
 		output.ByFile[el.File] = append(output.ByFile[el.File], ragEl)
 		output.ByType[el.Type] = append(output.ByType[el.Type], ragEl)
+		output.ByPackage[el.ImportPath] = append(output.ByPackage[el.ImportPath], ragEl)
 	}
 
 	// Sort elements
@@ -61,6 +117,15 @@ func (idx *Indexer) GetRAGIndex(groupBy string) (*RAGOutput, error) {
 		})
 	}
 
+	for _, pkgElements := range output.ByPackage {
+		sort.Slice(pkgElements, func(i, j int) bool {
+			if pkgElements[i].Type != pkgElements[j].Type {
+				return pkgElements[i].Type < pkgElements[j].Type
+			}
+			return pkgElements[i].Name < pkgElements[j].Name
+		})
+	}
+
 	for _, typeElements := range output.ByType {
 		sort.Slice(typeElements, func(i, j int) bool {
 			return typeElements[i].Name < typeElements[j].Name
@@ -75,7 +140,7 @@ func (idx *Indexer) GetRAGIndex(groupBy string) (*RAGOutput, error) {
 // buildSignature creates a readable signature for an element
 func buildSignature(el parser.CodeElement) string {
 	switch el.Type {
-	case parser.TypeFunction:
+	case parser.TypeFunction, parser.TypeTest, parser.TypeBenchmark, parser.TypeFuzz, parser.TypeExample:
 		params := make([]string, len(el.Params))
 		for i, p := range el.Params {
 			if p.Type != "" {
@@ -84,29 +149,99 @@ func buildSignature(el parser.CodeElement) string {
 				params[i] = p.Name
 			}
 		}
-		sig := fmt.Sprintf("%s(%s)", el.Name, strings.Join(params, ", "))
+		sig := fmt.Sprintf("%s%s(%s)", el.Name, formatTypeParams(el.TypeParams), strings.Join(params, ", "))
 		if el.Returns != "" {
 			sig += " " + el.Returns
 		}
 		return sig
 
-	case parser.TypeStruct:
+	case parser.TypeEndpoint:
+		params := make([]string, len(el.Params))
+		for i, p := range el.Params {
+			params[i] = strings.TrimSpace(p.Name + " " + p.Type)
+		}
+		sig := fmt.Sprintf("%s (%s)", el.Name, strings.Join(params, ", "))
+		if el.Returns != "" {
+			sig += " -> " + el.Returns
+		}
+		return sig
+
+	case parser.TypeStruct, parser.TypeSchema:
 		if len(el.Fields) > 0 {
-			return fmt.Sprintf("%s {%d fields}", el.Name, len(el.Fields))
+			return fmt.Sprintf("%s%s {%d fields}", el.Name, formatTypeParams(el.TypeParams), len(el.Fields))
+		}
+		return el.Name + formatTypeParams(el.TypeParams)
+
+	case parser.TypeClass:
+		sig := el.Name + formatTypeParams(el.TypeParams)
+		if el.Extends != "" {
+			sig += " extends " + el.Extends
 		}
-		return el.Name
+		if len(el.Methods) > 0 {
+			sig += fmt.Sprintf(" {%d methods}", len(el.Methods))
+		}
+		return sig
 
 	case parser.TypeInterface:
 		if len(el.Methods) > 0 {
-			return fmt.Sprintf("%s {%d methods}", el.Name, len(el.Methods))
+			return fmt.Sprintf("%s%s {%d methods}", el.Name, formatTypeParams(el.TypeParams), len(el.Methods))
+		}
+		return el.Name + formatTypeParams(el.TypeParams)
+
+	case parser.TypeImpl:
+		sig := "impl" + formatTypeParams(el.TypeParams) + " "
+		if len(el.Implements) > 0 {
+			sig += el.Implements[0] + " for "
+		}
+		return fmt.Sprintf("%s%s {%d methods}", sig, el.Name, len(el.Methods))
+
+	case parser.TypeTable, parser.TypeView, parser.TypeIndex:
+		columns := make([]string, len(el.Fields))
+		for i, f := range el.Fields {
+			columns[i] = strings.TrimSpace(f.Name + " " + f.Type)
+		}
+		sig := fmt.Sprintf("%s %s", el.Type, el.Name)
+		if el.Type == parser.TypeIndex {
+			sig += " on " + path.Base(strings.TrimPrefix(el.Context, "sql:"))
+		}
+		return fmt.Sprintf("%s (%s)", sig, strings.Join(columns, ", "))
+
+	case parser.TypeTarget:
+		return strings.TrimSpace(el.Name + ": " + strings.Join(el.Prerequisites, " "))
+
+	case parser.TypeConstant, parser.TypeVariable:
+		sig := el.Name
+		if el.ValueType != "" {
+			sig += " " + el.ValueType
 		}
-		return el.Name
+		if el.Value != "" {
+			sig += " = " + el.Value
+		}
+		return sig
+
+	case parser.TypePackage:
+		return fmt.Sprintf("package %s // %s, %d files, %d exported", el.Name, el.ImportPath, len(el.Files), el.ExportedSymbols)
 
 	default:
-		return el.Name
+		if len(el.EnumValues) > 0 {
+			return fmt.Sprintf("%s {%d values}", el.Name, len(el.EnumValues))
+		}
+		return el.Name + formatTypeParams(el.TypeParams)
 	}
 }
 
+// formatTypeParams renders generic type parameters as "[K comparable, V any]"
+func formatTypeParams(typeParams []parser.Parameter) string {
+	if len(typeParams) == 0 {
+		return ""
+	}
+	parts := make([]string, len(typeParams))
+	for i, tp := range typeParams {
+		parts[i] = strings.TrimSpace(tp.Name + " " + tp.Type)
+	}
+	return "[" + strings.Join(parts, ", ") + "]"
+}
+
 // generateSummary creates a text summary for RAG
 func generateSummary(output *RAGOutput) string {
 	var sb strings.Builder
@@ -165,9 +300,16 @@ func FormatRAGByFile(output *RAGOutput) string {
 			sb.WriteString(fmt.Sprintf("### %s %s\n", el.Type, el.Name))
 			sb.WriteString(fmt.Sprintf("**Location:** %s:%d\n", el.File, el.Line))
 			sb.WriteString(fmt.Sprintf("**Signature:** `%s`\n", el.Signature))
+			if el.ID != "" {
+				sb.WriteString(fmt.Sprintf("**ID:** `%s`\n", el.ID))
+			}
+			if el.Build != "" {
+				sb.WriteString(fmt.Sprintf("**Build:** %s\n", el.Build))
+			}
 			if el.Docstring != "" {
 				sb.WriteString(fmt.Sprintf("**Doc:** %s\n", strings.TrimSpace(el.Docstring)))
 			}
+			writeRAGRelations(&sb, el)
 			sb.WriteString("\n")
 		}
 	}
@@ -199,9 +341,16 @@ func FormatRAGByType(output *RAGOutput) string {
 			sb.WriteString(fmt.Sprintf("### %s\n", el.Name))
 			sb.WriteString(fmt.Sprintf("**Location:** %s:%d\n", el.File, el.Line))
 			sb.WriteString(fmt.Sprintf("**Signature:** `%s`\n", el.Signature))
+			if el.ID != "" {
+				sb.WriteString(fmt.Sprintf("**ID:** `%s`\n", el.ID))
+			}
+			if el.Build != "" {
+				sb.WriteString(fmt.Sprintf("**Build:** %s\n", el.Build))
+			}
 			if el.Docstring != "" {
 				sb.WriteString(fmt.Sprintf("**Doc:** %s\n", strings.TrimSpace(el.Docstring)))
 			}
+			writeRAGRelations(&sb, el)
 			sb.WriteString("\n")
 		}
 	}
@@ -209,6 +358,126 @@ func FormatRAGByType(output *RAGOutput) string {
 	return sb.String()
 }
 
+// writeRAGRelations writes the fields, enum values, method set and implemented
+// interfaces of a type, and the tests, examples and documentation of an element
+func writeRAGRelations(sb *strings.Builder, el RAGElement) {
+	if len(el.Decorators) > 0 {
+		sb.WriteString(fmt.Sprintf("**Decorators:** @%s\n", strings.Join(el.Decorators, ", @")))
+	}
+	if len(el.Fields) > 0 {
+		sb.WriteString("**Fields:**\n")
+		for _, field := range el.Fields {
+			sb.WriteString(fmt.Sprintf("- `%s`\n", field))
+		}
+	}
+	if len(el.EnumValues) > 0 {
+		sb.WriteString(fmt.Sprintf("**Values:** %s\n", strings.Join(el.EnumValues, ", ")))
+	}
+	if len(el.Methods) > 0 {
+		sb.WriteString(fmt.Sprintf("**Methods:** %s\n", strings.Join(el.Methods, ", ")))
+	}
+	if len(el.Implements) > 0 {
+		sb.WriteString(fmt.Sprintf("**Implements:** %s\n", strings.Join(el.Implements, ", ")))
+	}
+	if len(el.TestedBy) > 0 {
+		sb.WriteString(fmt.Sprintf("**Tested by:** %s\n", strings.Join(el.TestedBy, ", ")))
+	}
+	if len(el.ImplementedBy) > 0 {
+		sb.WriteString(fmt.Sprintf("**Implemented by:** %s\n", strings.Join(el.ImplementedBy, ", ")))
+	}
+	if el.Declaration != "" {
+		sb.WriteString(fmt.Sprintf("**Declared as:** %s\n", el.Declaration))
+	}
+	for _, example := range el.Examples {
+		sb.WriteString(fmt.Sprintf("**Example:**\n```go\n%s\n```\n", example))
+	}
+	for _, doc := range el.Docs {
+		sb.WriteString(fmt.Sprintf("**Documentation:**\n> %s\n", strings.ReplaceAll(doc, "\n", "\n> ")))
+	}
+}
+
+// FormatRAGByPackage formats RAG output as one summary per package: its
+// documentation, element counts and exported API
+func FormatRAGByPackage(output *RAGOutput) string {
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf("# Packages (%d elements)\n\n", output.TotalElements))
+
+	paths := make([]string, 0, len(output.ByPackage))
+	for p := range output.ByPackage {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	for _, pkgPath := range paths {
+		elements := output.ByPackage[pkgPath]
+		if pkgPath == "" {
+			sb.WriteString("## (no package)\n\n")
+		} else {
+			sb.WriteString(fmt.Sprintf("## %s\n\n", pkgPath))
+		}
+
+		counts := make(map[parser.ElementType]int)
+		unexported := 0
+		for _, el := range elements {
+			if el.Type == parser.TypePackage {
+				sb.WriteString(fmt.Sprintf("`%s`\n", el.Signature))
+				if el.Docstring != "" {
+					sb.WriteString(fmt.Sprintf("**Doc:** %s\n", firstParagraph(el.Docstring)))
+				}
+				continue
+			}
+			counts[el.Type]++
+			if !el.Exports {
+				unexported++
+			}
+		}
+
+		types := make([]parser.ElementType, 0, len(counts))
+		for t := range counts {
+			types = append(types, t)
+		}
+		sort.Slice(types, func(i, j int) bool {
+			return string(types[i]) < string(types[j])
+		})
+		parts := make([]string, len(types))
+		for i, t := range types {
+			parts[i] = fmt.Sprintf("%s: %d", t, counts[t])
+		}
+		sb.WriteString(fmt.Sprintf("**Contents:** %s\n\n", strings.Join(parts, ", ")))
+
+		for _, el := range elements {
+			if el.Type == parser.TypePackage || !el.Exports {
+				continue
+			}
+			sb.WriteString(fmt.Sprintf("- %s `%s` - %s:%d%s\n", el.Type, el.Signature, el.File, el.Line, formatBuild(el.Build)))
+		}
+		if unexported > 0 {
+			sb.WriteString(fmt.Sprintf("- (%d unexported)\n", unexported))
+		}
+		sb.WriteString("\n")
+	}
+
+	return sb.String()
+}
+
+// formatBuild renders a build constraint as a suffix for list entries
+func formatBuild(build string) string {
+	if build == "" {
+		return ""
+	}
+	return " [" + build + "]"
+}
+
+// firstParagraph returns the first paragraph of a doc comment on one line
+func firstParagraph(doc string) string {
+	doc = strings.TrimSpace(doc)
+	if i := strings.Index(doc, "\n\n"); i >= 0 {
+		doc = doc[:i]
+	}
+	return strings.Join(strings.Fields(doc), " ")
+}
+
 // FormatRAGCompact formats RAG output in compact list format
 func FormatRAGCompact(output *RAGOutput) string {
 	var sb strings.Builder
@@ -229,7 +498,7 @@ func FormatRAGCompact(output *RAGOutput) string {
 		sb.WriteString(fmt.Sprintf("\n## %s\n\n", elemType))
 
 		for _, el := range elements {
-			sb.WriteString(fmt.Sprintf("- `%s` - %s:%d\n", el.Signature, el.File, el.Line))
+			sb.WriteString(fmt.Sprintf("- `%s` - %s:%d%s\n", el.Signature, el.File, el.Line, formatBuild(el.Build)))
 		}
 	}
 
@@ -0,0 +1,108 @@
+package parser
+
+import "sort"
+
+// lineIndex holds the offset at which each line of a source starts
+type lineIndex []int
+
+// lineStarts indexes the lines of src
+func lineStarts(src string) lineIndex {
+	starts := lineIndex{0}
+	for i := 0; i < len(src); i++ {
+		if src[i] == '\n' {
+			starts = append(starts, i+1)
+		}
+	}
+	return starts
+}
+
+// line returns the 1-based line of a byte offset
+func (l lineIndex) line(offset int) int {
+	return sort.Search(len(l), func(i int) bool { return l[i] > offset })
+}
+
+// srcToken is what the tokens of every language have: their text and byte range
+type srcToken struct {
+	text string
+	pos  int
+	end  int
+}
+
+// source returns the token itself; a language's token embeds srcToken, and
+// gets it too
+func (t srcToken) source() srcToken {
+	return t
+}
+
+// sourceToken is a token of some language
+type sourceToken interface {
+	source() srcToken
+}
+
+// tokenCursor is the position of a recursive-descent parser in the tokens
+// of a source file
+type tokenCursor[T sourceToken] struct {
+	tokens []T
+	i      int
+	src    string
+	lines  lineIndex
+	eof    T // Returned past either end
+}
+
+// newTokenCursor creates a cursor at the first of tokens. eof stands for the
+// tokens past either end: an empty token at the end of src.
+func newTokenCursor[T sourceToken](tokens []T, src string, eof T) tokenCursor[T] {
+	return tokenCursor[T]{tokens: tokens, src: src, lines: lineStarts(src), eof: eof}
+}
+
+// tok returns the token at i, or an empty token past the end
+func (c *tokenCursor[T]) tok(i int) T {
+	if i < 0 || i >= len(c.tokens) {
+		return c.eof
+	}
+	return c.tokens[i]
+}
+
+// peek returns the current token's text
+func (c *tokenCursor[T]) peek() string {
+	return c.tok(c.i).source().text
+}
+
+// accept consumes the current token if its text is text
+func (c *tokenCursor[T]) accept(text string) bool {
+	if c.i < len(c.tokens) && c.tokens[c.i].source().text == text {
+		c.i++
+		return true
+	}
+	return false
+}
+
+// line returns the 1-based line of a byte offset
+func (c *tokenCursor[T]) line(offset int) int {
+	return c.lines.line(offset)
+}
+
+// text returns the source of tokens start to end (exclusive)
+func (c *tokenCursor[T]) text(start, end int) string {
+	if end <= start {
+		return ""
+	}
+	return c.src[c.tok(start).source().pos:c.tok(end-1).source().end]
+}
+
+// skipBalanced skips a bracketed token run starting at the current token
+func (c *tokenCursor[T]) skipBalanced() {
+	depth := 0
+	for c.i < len(c.tokens) {
+		switch c.tokens[c.i].source().text {
+		case "(", "[", "{":
+			depth++
+		case ")", "]", "}":
+			depth--
+		}
+		c.i++
+		if depth <= 0 {
+			return
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// asmText matches the TEXT directive opening a Go assembly function:
+// "TEXT ·add(SB), NOSPLIT, $0-24" or "TEXT runtime·memmove<ABIInternal>(SB)"
+var asmText = regexp.MustCompile(`^\s*TEXT\s+([^\s·(]*)·(\w+)(?:<\w+>)?\(SB\)`)
+
+// asmEnd matches the directives that end the previous function
+var asmEnd = regexp.MustCompile(`^\s*(TEXT|DATA|GLOBL)\b`)
+
+// loadForeign extracts the assembly and C functions of a package's directory.
+// They are linked to Go declarations by Link: assembly through Declaration,
+// C functions through the C.name calls of the cgo code calling them.
+func (l *GoLoader) loadForeign(dir, importPath string, result *ParseResult) []CodeElement {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+
+	paths := make([]string, 0)
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		if !entry.IsDir() && (ext == ".s" || ext == ".c") {
+			paths = append(paths, filepath.Join(dir, entry.Name()))
+		}
+	}
+	sort.Strings(paths)
+
+	elements := make([]CodeElement, 0)
+	module := l.module(dir).Path
+	for _, p := range paths {
+		relPath, relErr := filepath.Rel(l.root, p)
+		if relErr != nil {
+			relPath = p
+		}
+		content, err := os.ReadFile(p)
+		if err != nil {
+			result.Errors = append(result.Errors, ParseError{File: relPath, Message: err.Error()})
+			continue
+		}
+
+		expr := sourceBuildConstraint(p, string(content))
+		if l.target != nil && !l.target.Matches(expr) {
+			continue
+		}
+		constraint := ""
+		if expr != nil {
+			constraint = expr.String()
+		}
+
+		var found []CodeElement
+		if filepath.Ext(p) == ".s" {
+			found = asmFunctions(string(content), relPath, importPath)
+		} else {
+			found = cFunctions(string(content), 0, relPath, importPath)
+		}
+		for i := range found {
+			found[i].Module = module
+			found[i].BuildConstraint = constraint
+			found[i].Hash = HashCode(found[i].ID + "\n" + found[i].Body)
+		}
+		elements = append(elements, found...)
+		result.Files++
+	}
+	return elements
+}
+
+// asmFunctions extracts the functions defined by TEXT directives in a Go assembly file
+func asmFunctions(content, relPath, importPath string) []CodeElement {
+	lines := strings.Split(content, "\n")
+	elements := make([]CodeElement, 0)
+
+	for i := 0; i < len(lines); i++ {
+		m := asmText.FindStringSubmatch(lines[i])
+		if m == nil {
+			continue
+		}
+
+		// "·name" is a symbol of the package itself; "pkg∕path·name" one of another package
+		pkgPath := importPath
+		if m[1] != "" {
+			pkgPath = strings.ReplaceAll(m[1], "∕", "/")
+		}
+		declaration := pkgPath + "." + m[2]
+
+		end := i + 1
+		for end < len(lines) && !asmEnd.MatchString(lines[end]) {
+			end++
+		}
+		for end > i+1 && strings.TrimSpace(lines[end-1]) == "" {
+			end--
+		}
+
+		elements = append(elements, CodeElement{
+			Type:        TypeFunction,
+			Name:        m[2],
+			ID:          declaration + "@" + filepath.Base(relPath),
+			File:        relPath,
+			Line:        i + 1,
+			EndLine:     end,
+			Declaration: declaration,
+			ImportPath:  importPath,
+			Body:        strings.Join(lines[i:end], "\n"),
+			Docstring:   commentAbove(lines, i),
+			Language:    "asm",
+			IndexedAt:   time.Now(),
+		})
+		i = end - 1
+	}
+	return elements
+}
+
+// commentAbove returns the // or /* */ comment lines directly above line i, without their markers
+func commentAbove(lines []string, i int) string {
+	start := i
+	for start > 0 {
+		line := strings.TrimSpace(lines[start-1])
+		if !strings.HasPrefix(line, "//") && !strings.HasPrefix(line, "/*") &&
+			!strings.HasPrefix(line, "*") {
+			break
+		}
+		start--
+	}
+
+	text := make([]string, 0, i-start)
+	for _, line := range lines[start:i] {
+		line = strings.TrimSpace(line)
+		line = strings.TrimPrefix(line, "//")
+		line = strings.TrimPrefix(line, "/*")
+		line = strings.TrimSuffix(line, "*/")
+		line = strings.TrimPrefix(line, "*")
+		if line = strings.TrimSpace(line); line != "" {
+			text = append(text, line)
+		}
+	}
+	return strings.Join(text, "\n")
+}
@@ -0,0 +1,152 @@
+package parser
+
+import (
+	"go/ast"
+	"go/build"
+	"go/build/constraint"
+	"path/filepath"
+	"strings"
+)
+
+// Operating systems and architectures recognised in file name suffixes (as in go/build)
+var (
+	knownOS = map[string]bool{
+		"aix": true, "android": true, "darwin": true, "dragonfly": true, "freebsd": true,
+		"hurd": true, "illumos": true, "ios": true, "js": true, "linux": true, "nacl": true,
+		"netbsd": true, "openbsd": true, "plan9": true, "solaris": true, "wasip1": true,
+		"windows": true, "zos": true,
+	}
+	knownArch = map[string]bool{
+		"386": true, "amd64": true, "amd64p32": true, "arm": true, "armbe": true, "arm64": true,
+		"arm64be": true, "loong64": true, "mips": true, "mipsle": true, "mips64": true,
+		"mips64le": true, "mips64p32": true, "mips64p32le": true, "ppc": true, "ppc64": true,
+		"ppc64le": true, "riscv": true, "riscv64": true, "s390": true, "s390x": true,
+		"sparc": true, "sparc64": true, "wasm": true,
+	}
+	unixOS = map[string]bool{
+		"aix": true, "android": true, "darwin": true, "dragonfly": true, "freebsd": true,
+		"hurd": true, "illumos": true, "ios": true, "linux": true, "netbsd": true,
+		"openbsd": true, "solaris": true,
+	}
+)
+
+// BuildTarget restricts indexing to the files built for one GOOS/GOARCH/tag set
+type BuildTarget struct {
+	GOOS   string
+	GOARCH string
+	Tags   []string
+}
+
+// goBuildConstraint combines a file's //go:build line with the GOOS/GOARCH
+// implied by its name (foo_linux.go, foo_windows_amd64.go). It returns nil
+// for files built everywhere.
+func goBuildConstraint(fileName string, file *ast.File) constraint.Expr {
+	var expr constraint.Expr
+
+	// The //go:build line must come before the package clause
+	for _, group := range file.Comments {
+		if group.Pos() >= file.Package {
+			break
+		}
+		for _, c := range group.List {
+			if constraint.IsGoBuild(c.Text) {
+				if parsed, err := constraint.Parse(c.Text); err == nil {
+					expr = parsed
+				}
+			}
+		}
+	}
+
+	return andConstraint(expr, fileNameConstraint(fileName))
+}
+
+// sourceBuildConstraint is goBuildConstraint for the assembly and C files of a
+// package, whose //go:build line comes before the first line of code
+func sourceBuildConstraint(fileName, content string) constraint.Expr {
+	var expr constraint.Expr
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "//") {
+			break
+		}
+		if constraint.IsGoBuild(line) {
+			if parsed, err := constraint.Parse(line); err == nil {
+				expr = parsed
+			}
+		}
+	}
+	return andConstraint(expr, fileNameConstraint(fileName))
+}
+
+// fileNameConstraint is the constraint implied by a _GOOS, _GOARCH or _GOOS_GOARCH file name suffix
+func fileNameConstraint(fileName string) constraint.Expr {
+	name := filepath.Base(fileName)
+	name = strings.TrimSuffix(name, filepath.Ext(name))
+	name = strings.TrimSuffix(name, "_test")
+	parts := strings.Split(name, "_")
+
+	if n := len(parts); n >= 2 {
+		last := parts[n-1]
+		switch {
+		case n >= 3 && knownOS[parts[n-2]] && knownArch[last]:
+			return &constraint.AndExpr{
+				X: &constraint.TagExpr{Tag: parts[n-2]},
+				Y: &constraint.TagExpr{Tag: last},
+			}
+		case knownOS[last], knownArch[last]:
+			return &constraint.TagExpr{Tag: last}
+		}
+	}
+	return nil
+}
+
+// andConstraint combines two optional constraints
+func andConstraint(x, y constraint.Expr) constraint.Expr {
+	switch {
+	case x == nil:
+		return y
+	case y == nil:
+		return x
+	default:
+		return &constraint.AndExpr{X: x, Y: y}
+	}
+}
+
+// Matches reports whether a file with the given build constraint is built for the target
+func (t *BuildTarget) Matches(expr constraint.Expr) bool {
+	if expr == nil {
+		return true
+	}
+
+	goos, goarch := t.GOOS, t.GOARCH
+	if goos == "" {
+		goos = build.Default.GOOS
+	}
+	if goarch == "" {
+		goarch = build.Default.GOARCH
+	}
+
+	return expr.Eval(func(tag string) bool {
+		switch {
+		case tag == goos, tag == goarch, tag == "gc":
+			return true
+		case tag == "unix":
+			return unixOS[goos]
+		case tag == "linux" && goos == "android", tag == "darwin" && goos == "ios", tag == "solaris" && goos == "illumos":
+			return true
+		case tag == "cgo":
+			return build.Default.CgoEnabled
+		}
+		for _, release := range build.Default.ReleaseTags {
+			if tag == release {
+				return true
+			}
+		}
+		for _, custom := range t.Tags {
+			if tag == custom {
+				return true
+			}
+		}
+		return false
+	})
+}
@@ -0,0 +1,113 @@
+package parser
+
+import (
+	"go/ast"
+	"go/types"
+)
+
+// extractCalls lists the functions and methods called in a function body.
+// Type-checked callees are recorded by symbol ID, the ID their element has
+// when indexed; without type information the call expression is recorded as
+// written.
+func (p *GoParser) extractCalls(body *ast.BlockStmt, f *goFile) []string {
+	if body == nil {
+		return nil
+	}
+
+	calls := make([]string, 0)
+	seen := make(map[string]bool)
+	ast.Inspect(body, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+
+		// C functions are not Go objects, even to the type checker
+		callee := p.cgoCallee(call.Fun, f)
+		if callee == "" && f.info != nil {
+			if fn := p.calledFunc(call.Fun, f); fn != nil {
+				callee = funcID(fn)
+			}
+		} else if callee == "" {
+			callee = p.calledName(call.Fun)
+		}
+
+		if callee != "" && !seen[callee] {
+			seen[callee] = true
+			calls = append(calls, callee)
+		}
+		return true
+	})
+
+	if len(calls) == 0 {
+		return nil
+	}
+	return calls
+}
+
+// calledFunc resolves the function object a call expression invokes, if it is a declared function or method
+func (p *GoParser) calledFunc(fun ast.Expr, f *goFile) *types.Func {
+	switch t := fun.(type) {
+	case *ast.ParenExpr:
+		return p.calledFunc(t.X, f)
+	case *ast.IndexExpr: // Explicit instantiation: F[int](x)
+		return p.calledFunc(t.X, f)
+	case *ast.IndexListExpr:
+		return p.calledFunc(t.X, f)
+	case *ast.Ident:
+		fn, _ := f.info.Uses[t].(*types.Func)
+		return fn
+	case *ast.SelectorExpr:
+		fn, _ := f.info.Uses[t.Sel].(*types.Func)
+		return fn
+	}
+	return nil
+}
+
+// calledName renders a call target syntactically ("fmt.Println", "p.extract")
+func (p *GoParser) calledName(fun ast.Expr) string {
+	switch t := fun.(type) {
+	case *ast.ParenExpr:
+		return p.calledName(t.X)
+	case *ast.IndexExpr:
+		return p.calledName(t.X)
+	case *ast.IndexListExpr:
+		return p.calledName(t.X)
+	case *ast.Ident:
+		return t.Name
+	case *ast.SelectorExpr:
+		if x := p.calledName(t.X); x != "" {
+			return x + "." + t.Sel.Name
+		}
+	}
+	return ""
+}
+
+// funcID returns the symbol ID of a function, matching the ID of its element
+func funcID(fn *types.Func) string {
+	fn = fn.Origin()
+	name := fn.Name()
+	if sig, ok := fn.Type().(*types.Signature); ok && sig.Recv() != nil {
+		recv := sig.Recv().Type()
+		pointer := false
+		if ptr, ok := recv.(*types.Pointer); ok {
+			recv, pointer = ptr.Elem(), true
+		}
+		if named, ok := recv.(*types.Named); ok {
+			// Methods of anonymous interfaces have no receiver name to go by
+			name = methodSymbol(named.Obj().Name(), name, pointer)
+		}
+	}
+	if fn.Pkg() == nil {
+		return name // Builtin error.Error
+	}
+	return fn.Pkg().Path() + "." + name
+}
+
+// methodSymbol renders a method the way symbol IDs do: "(*T).M" or "T.M"
+func methodSymbol(recv, method string, pointer bool) string {
+	if pointer {
+		return "(*" + recv + ")." + method
+	}
+	return recv + "." + method
+}
@@ -0,0 +1,283 @@
+package parser
+
+import (
+	"go/ast"
+	"go/token"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cFuncHeader matches what precedes the body of a C function definition:
+// return type, name and parameter list ("static int add(int a, int b)")
+var cFuncHeader = regexp.MustCompile(`^([A-Za-z_][\w\s*]*?)\s*\b([A-Za-z_]\w*)\s*\(([^{;]*)\)$`)
+
+// cKeywords cannot be function names; a header ending in one is a statement
+var cKeywords = map[string]bool{
+	"if": true, "for": true, "while": true, "switch": true, "return": true, "sizeof": true,
+}
+
+// extractCgo extracts the C functions defined in the preamble of import "C"
+func (p *GoParser) extractCgo(file *ast.File, f *goFile) []CodeElement {
+	elements := make([]CodeElement, 0)
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.IMPORT {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			imp := spec.(*ast.ImportSpec)
+			if path, _ := strconv.Unquote(imp.Path.Value); path != "C" {
+				continue
+			}
+			// The preamble is the comment right above import "C"
+			doc := imp.Doc
+			if doc == nil && !gen.Lparen.IsValid() {
+				doc = gen.Doc
+			}
+			if doc == nil {
+				continue
+			}
+			start := f.fset.Position(doc.Pos())
+			elements = append(elements, cFunctions(cgoPreamble(doc, f), start.Line-1, f.path, f.importPath)...)
+		}
+	}
+	return elements
+}
+
+// cgoPreamble returns the source of a preamble comment with the comment
+// markers blanked out, so that lines and columns still line up with the file
+func cgoPreamble(doc *ast.CommentGroup, f *goFile) string {
+	var sb strings.Builder
+	prevLine := f.fset.Position(doc.Pos()).Line
+	for _, c := range doc.List {
+		line := f.fset.Position(c.Pos()).Line
+		sb.WriteString(strings.Repeat("\n", line-prevLine))
+		prevLine = line + strings.Count(c.Text, "\n")
+
+		text := c.Text
+		if strings.HasPrefix(text, "//") {
+			text = "  " + text[2:]
+		} else {
+			text = "  " + strings.TrimSuffix(text[2:], "*/")
+		}
+		sb.WriteString(text)
+	}
+	return sb.String()
+}
+
+// cFunctions extracts the function definitions of C source. lineOffset is the
+// line of the file the source starts after; every function belongs to the
+// cgo pseudo-package C of importPath, the way Go code refers to it.
+func cFunctions(source string, lineOffset int, relPath, importPath string) []CodeElement {
+	lines := strings.Split(source, "\n")
+	code := cCode(source)
+	elements := make([]CodeElement, 0)
+
+	depth := 0
+	headerStart := 0
+	for i := 0; i < len(code); i++ {
+		switch code[i] {
+		case ';':
+			if depth == 0 {
+				headerStart = i + 1
+			}
+		case '{':
+			if depth == 0 {
+				header := strings.Join(strings.Fields(code[headerStart:i]), " ")
+				if m := cFuncHeader.FindStringSubmatch(header); m != nil && !cKeywords[m[2]] {
+					end := matchingBrace(code, i)
+					elements = append(elements, cFunction(m, lines, code, headerStart, end, lineOffset, relPath, importPath))
+					i = end
+					headerStart = i + 1
+					continue
+				}
+			}
+			depth++
+		case '}':
+			if depth > 0 {
+				depth--
+			}
+			if depth == 0 {
+				headerStart = i + 1
+			}
+		}
+	}
+	return elements
+}
+
+// cFunction builds the element of a C function whose header starts at start and body ends at end
+func cFunction(m []string, lines []string, code string, start, end, lineOffset int, relPath, importPath string) CodeElement {
+	// Skip the whitespace between the previous declaration and this one
+	for start < end && (code[start] == ' ' || code[start] == '\n' || code[start] == '\t' || code[start] == '\r') {
+		start++
+	}
+	first := strings.Count(code[:start], "\n")
+	last := strings.Count(code[:end], "\n")
+
+	returns := strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(m[1], "static "), "inline "))
+	if returns == "void" {
+		returns = ""
+	}
+
+	return CodeElement{
+		Type:       TypeFunction,
+		Name:       m[2],
+		ID:         importPath + ".C." + m[2],
+		File:       relPath,
+		Line:       lineOffset + first + 1,
+		EndLine:    lineOffset + last + 1,
+		Params:     cParams(m[3]),
+		Returns:    returns,
+		ImportPath: importPath,
+		Body:       strings.Join(lines[first:last+1], "\n"),
+		Docstring:  commentAbove(lines, first),
+		Exports:    !strings.Contains(" "+m[1]+" ", " static "),
+		Language:   "c",
+		IndexedAt:  time.Now(),
+	}
+}
+
+// cParams splits a C parameter list ("int a, const char *s") into parameters
+func cParams(list string) []Parameter {
+	list = strings.TrimSpace(list)
+	if list == "" || list == "void" {
+		return nil
+	}
+
+	params := make([]Parameter, 0)
+	depth := 0
+	start := 0
+	for i := 0; i <= len(list); i++ {
+		if i < len(list) {
+			switch list[i] {
+			case '(':
+				depth++
+				continue
+			case ')':
+				depth--
+				continue
+			case ',':
+				if depth > 0 {
+					continue
+				}
+			default:
+				continue
+			}
+		}
+
+		param := strings.TrimSpace(list[start:i])
+		start = i + 1
+		if param == "..." {
+			params = append(params, Parameter{Name: "...", Optional: true})
+			continue
+		}
+		// The name is the last identifier, unless the type stands alone
+		cut := strings.LastIndexAny(param, " *")
+		if cut < 0 || strings.ContainsAny(param, "()") {
+			params = append(params, Parameter{Type: param})
+			continue
+		}
+		params = append(params, Parameter{
+			Name: param[cut+1:],
+			Type: strings.TrimSpace(param[:cut+1]),
+		})
+	}
+	return params
+}
+
+// cCode blanks out the comments, string and character literals and
+// preprocessor lines of C source, keeping newlines so offsets still match
+func cCode(source string) string {
+	code := []byte(source)
+	blank := func(from, to int) {
+		for i := from; i < to && i < len(code); i++ {
+			if code[i] != '\n' {
+				code[i] = ' '
+			}
+		}
+	}
+
+	lineStart := true
+	for i := 0; i < len(code); i++ {
+		c := code[i]
+		switch {
+		case c == '\n':
+			lineStart = true
+			continue
+		case c == ' ' || c == '\t' || c == '\r':
+			continue
+		case lineStart && c == '#':
+			// A directive runs to the end of the line, including continuations
+			end := i
+			for end < len(code) && (code[end] != '\n' || code[end-1] == '\\') {
+				end++
+			}
+			blank(i, end)
+			i = end - 1
+		case c == '/' && i+1 < len(code) && code[i+1] == '/':
+			end := strings.IndexByte(source[i:], '\n')
+			if end < 0 {
+				end = len(code) - i
+			}
+			blank(i, i+end)
+			i += end - 1
+		case c == '/' && i+1 < len(code) && code[i+1] == '*':
+			end := strings.Index(source[i+2:], "*/")
+			if end < 0 {
+				end = len(code) - i - 4
+			}
+			blank(i, i+end+4)
+			i += end + 3
+		case c == '"' || c == '\'':
+			end := i + 1
+			for end < len(code) && code[end] != c && code[end] != '\n' {
+				if code[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			blank(i+1, end)
+			i = end
+		}
+		lineStart = false
+	}
+	return string(code)
+}
+
+// matchingBrace returns the offset of the brace closing the one at open (or the end of code)
+func matchingBrace(code string, open int) int {
+	depth := 0
+	for i := open; i < len(code); i++ {
+		switch code[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return len(code) - 1
+}
+
+// cgoCallee returns the ID of the C function a call like C.add(x) invokes
+// ("" for anything else), matching the IDs cFunctions gives them
+func (p *GoParser) cgoCallee(fun ast.Expr, f *goFile) string {
+	sel, ok := fun.(*ast.SelectorExpr)
+	if !ok {
+		return ""
+	}
+	x, ok := sel.X.(*ast.Ident)
+	if !ok || x.Name != "C" {
+		return ""
+	}
+	for _, imp := range f.imports {
+		if imp == "C" {
+			return f.symbolID("C." + sel.Sel.Name)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,233 @@
+package parser
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strconv"
+	"time"
+)
+
+// funcLitWalker finds function literals that have a name by position: the
+// value of a variable, an entry of a map with string keys or a field of a
+// struct literal. Handlers and strategy tables tend to live there.
+type funcLitWalker struct {
+	p        *GoParser
+	f        *goFile
+	elements []CodeElement
+	ids      map[string]int // Literals indexed under each ID, to number repeated ones
+}
+
+// extractFuncLits indexes the named function literals of a file as functions
+func (p *GoParser) extractFuncLits(file *ast.File, f *goFile) []CodeElement {
+	w := &funcLitWalker{p: p, f: f, elements: make([]CodeElement, 0), ids: make(map[string]int)}
+
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.GenDecl:
+			if d.Tok != token.VAR {
+				continue
+			}
+			for _, spec := range d.Specs {
+				vs, ok := spec.(*ast.ValueSpec)
+				if !ok {
+					continue
+				}
+				docstring := p.extractDocstring(vs.Doc)
+				if !d.Lparen.IsValid() {
+					docstring = p.extractDocstring(d.Doc)
+				}
+				for i, name := range vs.Names {
+					if i >= len(vs.Values) {
+						continue
+					}
+					first := len(w.elements)
+					w.named(vs.Values[i], name.Name, "", "")
+					if first < len(w.elements) && w.elements[first].Name == name.Name {
+						w.elements[first].Docstring = docstring
+					}
+				}
+			}
+
+		case *ast.FuncDecl:
+			if d.Body == nil {
+				continue
+			}
+			context := d.Name.Name
+			symbol := context
+			if d.Recv != nil && d.Recv.NumFields() > 0 {
+				recvExpr := d.Recv.List[0].Type
+				recvType := p.getReceiverType(recvExpr)
+				_, pointer := recvExpr.(*ast.StarExpr)
+				context = recvType + "." + context
+				symbol = methodSymbol(recvType, d.Name.Name, pointer)
+			}
+			w.walk(d.Body, context, symbol)
+		}
+	}
+
+	return w.elements
+}
+
+// named handles an expression in a named position. context and symbol
+// describe the enclosing function ("" at package level).
+func (w *funcLitWalker) named(expr ast.Expr, name, context, symbol string) {
+	switch t := expr.(type) {
+	case *ast.FuncLit:
+		w.add(t, name, context, symbol)
+		w.walk(t.Body, context, symbol)
+
+	case *ast.UnaryExpr: // &Router{...}
+		w.named(t.X, name, context, symbol)
+
+	case *ast.ParenExpr:
+		w.named(t.X, name, context, symbol)
+
+	case *ast.CallExpr: // http.HandlerFunc(func(w, r) {...})
+		if len(t.Args) != 1 || !isFuncLit(t.Args[0]) {
+			w.walk(t, context, symbol)
+			return
+		}
+		w.walk(t.Fun, context, symbol)
+		w.named(t.Args[0], name, context, symbol)
+
+	case *ast.CompositeLit:
+		for _, elt := range t.Elts {
+			kv, ok := elt.(*ast.KeyValueExpr)
+			if !ok {
+				w.walk(elt, context, symbol)
+				continue
+			}
+			switch key := kv.Key.(type) {
+			case *ast.BasicLit:
+				if key.Kind == token.STRING {
+					w.named(kv.Value, name+"["+key.Value+"]", context, symbol)
+					continue
+				}
+			case *ast.Ident:
+				if w.isStructLit(t) { // Not a constant keying a map or array
+					w.named(kv.Value, name+"."+key.Name, context, symbol)
+					continue
+				}
+			}
+			w.walk(kv.Value, context, symbol)
+		}
+
+	default:
+		w.walk(expr, context, symbol)
+	}
+}
+
+// walk looks for named positions inside a node: assignments to a single
+// name, and composite literals, which are named after their type
+func (w *funcLitWalker) walk(node ast.Node, context, symbol string) {
+	ast.Inspect(node, func(n ast.Node) bool {
+		switch t := n.(type) {
+		case *ast.AssignStmt:
+			for i, rhs := range t.Rhs {
+				name := ""
+				if len(t.Lhs) == len(t.Rhs) {
+					if ident, ok := t.Lhs[i].(*ast.Ident); ok && ident.Name != "_" {
+						name = ident.Name
+					}
+				}
+				// A local closure is part of its function; a table of them is not
+				if name != "" && !isFuncLit(rhs) {
+					w.named(rhs, name, context, symbol)
+				} else {
+					w.walk(rhs, context, symbol)
+				}
+			}
+			return false
+
+		case *ast.CompositeLit:
+			name := "{}"
+			if t.Type != nil {
+				name = w.p.getReceiverType(unstar(t.Type))
+				if sel, ok := t.Type.(*ast.SelectorExpr); ok {
+					name = w.p.exprToString(sel)
+				}
+			}
+			w.named(t, name, context, symbol)
+			return false
+		}
+		return true
+	})
+}
+
+// isFuncLit reports whether expr is a function literal, possibly wrapped in
+// parentheses or in single-argument calls and conversions
+func isFuncLit(expr ast.Expr) bool {
+	switch t := expr.(type) {
+	case *ast.FuncLit:
+		return true
+	case *ast.ParenExpr:
+		return isFuncLit(t.X)
+	case *ast.CallExpr:
+		return len(t.Args) == 1 && isFuncLit(t.Args[0])
+	}
+	return false
+}
+
+// isStructLit reports whether a composite literal builds a struct, so that
+// its identifier keys are field names
+func (w *funcLitWalker) isStructLit(lit *ast.CompositeLit) bool {
+	switch lit.Type.(type) {
+	case *ast.MapType, *ast.ArrayType:
+		return false
+	}
+	if w.f.info != nil {
+		if tv, ok := w.f.info.Types[lit]; ok && tv.Type != nil {
+			_, isStruct := tv.Type.Underlying().(*types.Struct)
+			return isStruct
+		}
+	}
+	return true // A named type the checker could not resolve; most likely a struct
+}
+
+// add indexes a function literal under name. Literals named alike in one
+// function (two http.Server{Handler: ...}) are numbered from the second on:
+// http.Server.Handler#2.
+func (w *funcLitWalker) add(lit *ast.FuncLit, name, context, symbol string) {
+	p, f := w.p, w.f
+
+	id := name
+	if symbol != "" {
+		id = symbol + "." + name
+	}
+	w.ids[id]++
+	if n := w.ids[id]; n > 1 {
+		name += "#" + strconv.Itoa(n)
+		id += "#" + strconv.Itoa(n)
+	}
+
+	params := p.extractParams(lit.Type.Params, f)
+	body := p.extractNodeBody(lit.Pos(), lit.End(), f)
+	pos := f.fset.Position(lit.Pos())
+	endPos := f.fset.Position(lit.End())
+
+	exported := false
+	if context == "" {
+		exported = ast.IsExported(name)
+	}
+
+	w.elements = append(w.elements, CodeElement{
+		Type:      TypeFunction,
+		Name:      name,
+		ID:        f.symbolID(id),
+		File:      f.path,
+		Line:      pos.Line,
+		EndLine:   endPos.Line,
+		Hash:      HashCode(body),
+		Params:    params,
+		Returns:   p.extractReturns(lit.Type.Results, f),
+		Calls:     p.extractCalls(lit.Body, f),
+		Metrics:   p.extractMetrics(lit.Body, lit.Pos(), lit.End(), params, f),
+		Context:   context,
+		Body:      body,
+		Imports:   f.imports,
+		Exports:   exported,
+		Language:  "go",
+		IndexedAt: time.Now(),
+	})
+}
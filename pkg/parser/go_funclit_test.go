@@ -0,0 +1,62 @@
+package parser
+
+import "testing"
+
+func TestGoFuncLitNames(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want []string // IDs that must be indexed once, as functions
+		none []string // IDs that must not
+	}{
+		{
+			name: "assigned literal",
+			src:  "var h = func(int) {}\n",
+			want: []string{"example.com/s.h"},
+		},
+		{
+			name: "literal in a conversion",
+			src:  "type HandlerFunc func(int)\n\nvar h = HandlerFunc(func(int) {})\n",
+			want: []string{"example.com/s.h"},
+		},
+		{
+			name: "literal in nested calls and parentheses",
+			src:  "type HandlerFunc func(int)\n\nfunc wrap(h HandlerFunc) HandlerFunc { return h }\n\nvar h = wrap((HandlerFunc(func(int) {})))\n",
+			want: []string{"example.com/s.h"},
+		},
+		{
+			name: "struct field set through a conversion",
+			src:  "type HandlerFunc func(int)\n\ntype Server struct{ Handler HandlerFunc }\n\nvar s = Server{Handler: HandlerFunc(func(int) {})}\n",
+			want: []string{"example.com/s.s.Handler"},
+		},
+		{
+			name: "local closure in a conversion stays part of its function",
+			src:  "type HandlerFunc func(int)\n\nfunc F() { h := HandlerFunc(func(int) {}); h(0) }\n",
+			none: []string{"example.com/s.F.h", "example.com/s.F.HandlerFunc"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := writeTree(t, map[string]string{
+				"go.mod": "module example.com/s\n\ngo 1.22\n",
+				"s.go":   "package s\n\n" + tt.src,
+			})
+			result := loadDir(t, root, ".")
+
+			ids := make(map[string][]ElementType)
+			for _, el := range result.Elements {
+				ids[el.ID] = append(ids[el.ID], el.Type)
+			}
+			for _, id := range tt.want {
+				if len(ids[id]) != 1 || ids[id][0] != TypeFunction {
+					t.Errorf("%s indexed as %v, want one function; IDs: %v", id, ids[id], ids)
+				}
+			}
+			for _, id := range tt.none {
+				if len(ids[id]) > 0 {
+					t.Errorf("%s should not be indexed", id)
+				}
+			}
+		})
+	}
+}
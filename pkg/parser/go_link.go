@@ -0,0 +1,292 @@
+package parser
+
+import (
+	"go/types"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// elementKey identifies a package-level declaration by file and name
+type elementKey struct {
+	file string
+	name string
+}
+
+// recordTypeNames remembers the type-checker objects behind extracted type elements
+func (l *GoLoader) recordTypeNames(pkg *goPackage, elements []CodeElement) {
+	if pkg.types == nil {
+		return
+	}
+	for _, el := range elements {
+		switch el.Type {
+		case TypeStruct, TypeInterface, TypeType:
+		default:
+			continue
+		}
+		if tn, ok := pkg.types.Scope().Lookup(el.Name).(*types.TypeName); ok {
+			l.typeNames[elementKey{file: el.File, name: el.Name}] = tn
+		}
+	}
+}
+
+// Link is the post-parse pass over elements produced by LoadDir: it fills
+// Methods of every named type from its method set (value and pointer
+// receivers, including promoted methods), and Implements with the indexed
+// interfaces the type satisfies, qualified by package name ("parser.Parser").
+// The links between elements of any language are made by the package's Link.
+func (l *GoLoader) Link(elements []CodeElement) {
+	interfaces := make([]*types.TypeName, 0)
+	for _, el := range elements {
+		tn := l.typeNames[elementKey{file: el.File, name: el.Name}]
+		if tn == nil || !types.IsInterface(tn.Type()) || isGeneric(tn) {
+			continue
+		}
+		// Everything satisfies an empty interface, which says nothing
+		if tn.Type().Underlying().(*types.Interface).NumMethods() == 0 {
+			continue
+		}
+		interfaces = append(interfaces, tn)
+	}
+
+	for i := range elements {
+		el := &elements[i]
+		tn := l.typeNames[elementKey{file: el.File, name: el.Name}]
+		if tn == nil {
+			continue
+		}
+
+		el.Methods = methodSetNames(tn)
+
+		if types.IsInterface(tn.Type()) || isGeneric(tn) {
+			continue
+		}
+		implements := make([]string, 0)
+		for _, iface := range interfaces {
+			if iface == tn {
+				continue
+			}
+			it := iface.Type().Underlying().(*types.Interface)
+			if types.Implements(tn.Type(), it) || types.Implements(types.NewPointer(tn.Type()), it) {
+				implements = append(implements, iface.Pkg().Name()+"."+iface.Name())
+			}
+		}
+		sort.Strings(implements)
+		if len(implements) > 0 {
+			el.Implements = implements
+		}
+	}
+}
+
+// methodSetNames lists the methods callable on an addressable value of the named type
+func methodSetNames(tn *types.TypeName) []string {
+	typ := tn.Type()
+	if !types.IsInterface(typ) {
+		typ = types.NewPointer(typ)
+	}
+
+	mset := types.NewMethodSet(typ)
+	names := make([]string, 0, mset.Len())
+	for i := 0; i < mset.Len(); i++ {
+		method := mset.At(i).Obj()
+		// Unexported methods promoted from other packages cannot be called
+		if !method.Exported() && method.Pkg() != tn.Pkg() {
+			continue
+		}
+		names = append(names, method.Name())
+	}
+	return names
+}
+
+// isGeneric reports whether the named type declares type parameters
+func isGeneric(tn *types.TypeName) bool {
+	named, ok := tn.Type().(*types.Named)
+	return ok && named.TypeParams().Len() > 0
+}
+
+// linkEnums attaches Go enum constants to the named type they belong to. A
+// package is a directory, and the type may be declared in another file.
+// Other languages declare their enum values with the type; a .proto enum
+// keeps its values though the generated Go type sits in the same directory.
+func linkEnums(elements []CodeElement) {
+	values := make(map[elementKey][]*CodeElement)
+	for i := range elements {
+		el := &elements[i]
+		if el.Language == "go" && el.Type == TypeConstant && el.EnumOf != "" {
+			key := elementKey{file: filepath.Dir(el.File), name: el.EnumOf}
+			values[key] = append(values[key], el)
+		}
+	}
+
+	for i := range elements {
+		el := &elements[i]
+		if el.Language != "go" || el.Type == TypeConstant || el.Type == TypeVariable || el.Type.IsFunction() {
+			continue
+		}
+		consts := values[elementKey{file: filepath.Dir(el.File), name: el.Name}]
+		if len(consts) == 0 {
+			continue
+		}
+		sort.SliceStable(consts, func(a, b int) bool {
+			if consts[a].File != consts[b].File {
+				return consts[a].File < consts[b].File
+			}
+			return consts[a].Line < consts[b].Line
+		})
+		el.EnumValues = make([]string, len(consts))
+		for j, c := range consts {
+			el.EnumValues[j] = c.Name
+		}
+	}
+}
+
+// goBaseID returns the ID of a Go element without the "@file" that tells
+// build variants of one symbol apart (see distinguishVariants)
+func goBaseID(el *CodeElement) string {
+	if el.Language != "go" {
+		return el.ID
+	}
+	return strings.TrimSuffix(el.ID, "@"+filepath.Base(el.File))
+}
+
+// linkImplementations lists the assembly functions implementing a bodiless Go
+// declaration on it, and gives them the declaration's signature. Of build
+// variants of the declaration, the one built under the same constraint is taken.
+func linkImplementations(elements []CodeElement) {
+	declarations := make(map[string][]*CodeElement)
+	for i := range elements {
+		if el := &elements[i]; el.Language == "go" && el.Type.IsFunction() {
+			declarations[goBaseID(el)] = append(declarations[goBaseID(el)], el)
+		}
+	}
+
+	for i := range elements {
+		el := &elements[i]
+		variants := declarations[el.Declaration]
+		if el.Declaration == "" || len(variants) == 0 {
+			continue
+		}
+		decl := variants[0]
+		for _, v := range variants {
+			if v.BuildConstraint == el.BuildConstraint {
+				decl = v
+			}
+		}
+		decl.ImplementedBy = append(decl.ImplementedBy, el.ID)
+		el.Params = decl.Params
+		el.Returns = decl.Returns
+		if el.Docstring == "" {
+			el.Docstring = decl.Docstring
+		}
+	}
+}
+
+// linkCallers fills CalledBy, the reverse of Calls, for indexed functions.
+// A call names the symbol; each of its build variants is called.
+func linkCallers(elements []CodeElement) {
+	functions := make(map[string][]*CodeElement)
+	for i := range elements {
+		if el := &elements[i]; el.Type.IsFunction() {
+			functions[goBaseID(el)] = append(functions[goBaseID(el)], el)
+		}
+	}
+
+	for _, el := range elements {
+		if !el.Type.IsFunction() {
+			continue
+		}
+		caller := el.ID
+		for _, call := range el.Calls {
+			for _, callee := range functions[call] {
+				callee.CalledBy = append(callee.CalledBy, caller)
+			}
+		}
+	}
+}
+
+// linkTests links test, benchmark, fuzz and example functions to the element
+// they exercise. The name says it by convention (TestIndexer_Search,
+// ExampleIndexer_Search_empty); failing that, the first function of the
+// package under test that the test calls is taken as its subject.
+func linkTests(elements []CodeElement) {
+	byName := make(map[string]*CodeElement) // "path/to/pkg.Type.Method"
+	byID := make(map[string]*CodeElement)
+	packages := make(map[string]*CodeElement)
+	for i := range elements {
+		el := &elements[i]
+		switch {
+		case el.Type == TypePackage:
+			packages[el.ImportPath] = el
+		case el.Type == TypeFunction || !el.Type.IsFunction():
+			byName[el.ImportPath+"."+el.Name] = el
+			byID[el.ID] = el
+		}
+	}
+
+	for i := range elements {
+		test := &elements[i]
+		prefix := ""
+		switch test.Type {
+		case TypeTest:
+			prefix = "Test"
+		case TypeBenchmark:
+			prefix = "Benchmark"
+		case TypeFuzz:
+			prefix = "Fuzz"
+		case TypeExample:
+			prefix = "Example"
+		default:
+			continue
+		}
+
+		// External tests (package foo_test) exercise package foo
+		pkgPath := strings.TrimSuffix(test.ImportPath, "_test")
+
+		var subject *CodeElement
+		rest := strings.TrimPrefix(strings.TrimPrefix(test.Name, prefix), "_")
+		if rest == "" && test.Type == TypeExample {
+			subject = packages[pkgPath]
+		}
+		for _, candidate := range testSubjectNames(rest) {
+			if subject != nil {
+				break
+			}
+			if el, ok := byName[pkgPath+"."+candidate]; ok && !strings.HasSuffix(el.File, "_test.go") {
+				subject = el
+			}
+		}
+		for _, call := range test.Calls {
+			if subject != nil {
+				break
+			}
+			if el, ok := byID[call]; ok && el.ImportPath == pkgPath && !strings.HasSuffix(el.File, "_test.go") {
+				subject = el
+			}
+		}
+		if subject == nil {
+			continue
+		}
+
+		test.Subject = subject.ID
+		if test.Type == TypeExample {
+			subject.Examples = append(subject.Examples, test.ID)
+		} else {
+			subject.TestedBy = append(subject.TestedBy, test.ID)
+		}
+	}
+}
+
+// testSubjectNames lists the element names a test name suffix may refer to,
+// most specific first: "Indexer_Search_empty" -> Indexer.Search.empty,
+// Indexer.Search, Indexer
+func testSubjectNames(rest string) []string {
+	if rest == "" {
+		return nil
+	}
+	parts := strings.Split(rest, "_")
+	names := make([]string, 0, len(parts))
+	for n := len(parts); n > 0; n-- {
+		names = append(names, strings.Join(parts[:n], "."))
+	}
+	return names
+}
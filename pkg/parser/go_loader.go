@@ -0,0 +1,432 @@
+package parser
+
+import (
+	"fmt"
+	"go/ast"
+	"go/build/constraint"
+	"go/importer"
+	"go/token"
+	"go/types"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// GoLoader parses and type-checks whole Go packages with go/types, so that
+// signatures are rendered the way the type checker sees them instead of
+// being reconstructed from syntax one file at a time
+type GoLoader struct {
+	root      string
+	modules   []GoModule // Deepest first, so the first containing module wins
+	parser    *GoParser
+	fset      *token.FileSet
+	fallback  types.Importer
+	packages  map[string]*goPackage
+	typeNames map[elementKey]*types.TypeName
+	target    *BuildTarget
+}
+
+// goPackage is a parsed and type-checked package
+type goPackage struct {
+	path    string
+	sources []goSource
+	types   *types.Package
+	info    *types.Info
+	errors  []ParseError // Of parsing the library files, reported by LoadDir however the package was first loaded
+}
+
+// goSource is a single parsed file of a package
+type goSource struct {
+	path       string
+	content    []byte
+	file       *ast.File
+	constraint constraint.Expr
+	recovered  bool // Pieced together from a file with syntax errors
+}
+
+// NewGoLoader creates a loader for the tree rooted at root. Every module found
+// under root (through go.work or a nested go.mod) is loaded with its own path;
+// skip, when not nil, leaves directories out of the search (see FindModules).
+func NewGoLoader(root string, skip func(relPath string) bool) *GoLoader {
+	fset := token.NewFileSet()
+	return &GoLoader{
+		root:      root,
+		modules:   FindModules(root, skip),
+		parser:    NewGoParser(),
+		fset:      fset,
+		fallback:  importer.ForCompiler(fset, "source", nil),
+		packages:  make(map[string]*goPackage),
+		typeNames: make(map[elementKey]*types.TypeName),
+	}
+}
+
+// SetBuildTarget restricts loading to the files built for target. Without a
+// target every build variant is indexed, and the host's variant type-checked.
+func (l *GoLoader) SetBuildTarget(target *BuildTarget) {
+	l.target = target
+}
+
+// ModulePath returns the module path read from the root go.mod ("" without one)
+func (l *GoLoader) ModulePath() string {
+	for _, m := range l.modules {
+		if m.Dir == l.root {
+			return m.Path
+		}
+	}
+	return ""
+}
+
+// Modules returns the modules found under the root, deepest first
+func (l *GoLoader) Modules() []GoModule {
+	return l.modules
+}
+
+// LoadDir type-checks the package in dir (including its tests) and extracts its elements.
+// Type errors are tolerated: whatever the checker could not resolve falls back to syntax.
+func (l *GoLoader) LoadDir(dir string) (*ParseResult, error) {
+	result := &ParseResult{
+		Elements: make([]CodeElement, 0),
+		Errors:   make([]ParseError, 0),
+	}
+
+	libFiles, testFiles, err := l.listGoFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	importPath := l.importPath(dir)
+	lib, ok := l.packages[importPath]
+	if !ok || lib == nil {
+		lib = l.checkLibrary(importPath, libFiles)
+		l.packages[importPath] = lib
+	}
+	result.Errors = append(result.Errors, lib.errors...)
+	result.Files += len(lib.sources)
+	libElements := l.extract(lib, lib.sources)
+	distinguishVariants(libElements)
+	if len(lib.sources) > 0 {
+		result.Elements = append(result.Elements, l.packageElement(importPath, lib, libElements))
+	}
+	result.Elements = append(result.Elements, libElements...)
+	if len(lib.sources) > 0 {
+		result.Elements = append(result.Elements, l.loadForeign(dir, importPath, result)...)
+	}
+
+	if len(testFiles) == 0 {
+		return result, nil
+	}
+
+	// In-package tests are checked together with the library files, external
+	// (package foo_test) tests as a package of their own importing the library
+	internal := make([]goSource, 0)
+	external := make([]goSource, 0)
+	for _, src := range l.parseFiles(testFiles, result) {
+		result.Files++
+		if lib.types != nil && len(lib.sources) > 0 && src.file.Name.Name != lib.types.Name() {
+			external = append(external, src)
+		} else {
+			internal = append(internal, src)
+		}
+	}
+
+	if len(internal) > 0 {
+		pkg := l.check(importPath, append(append([]goSource{}, lib.sources...), internal...))
+		result.Elements = append(result.Elements, l.extract(pkg, internal)...)
+	}
+	if len(external) > 0 {
+		pkg := l.check(importPath+"_test", external)
+		result.Elements = append(result.Elements, l.extract(pkg, external)...)
+	}
+
+	return result, nil
+}
+
+// Import implements types.Importer
+func (l *GoLoader) Import(path string) (*types.Package, error) {
+	return l.ImportFrom(path, l.root, 0)
+}
+
+// ImportFrom implements types.ImporterFrom. Packages of the loaded module are
+// type-checked by the loader itself so that every package shares one view of
+// the module's types; everything else is imported from source.
+func (l *GoLoader) ImportFrom(importPath, dir string, mode types.ImportMode) (*types.Package, error) {
+	if dir, ok := l.moduleDir(importPath); ok {
+		if pkg, seen := l.packages[importPath]; seen {
+			if pkg == nil {
+				return nil, fmt.Errorf("import cycle through %s", importPath)
+			}
+			return pkg.types, nil
+		}
+
+		l.packages[importPath] = nil // Mark as in progress
+		libFiles, _, err := l.listGoFiles(dir)
+		if err != nil {
+			delete(l.packages, importPath)
+			return nil, err
+		}
+		pkg := l.checkLibrary(importPath, libFiles)
+		l.packages[importPath] = pkg
+		return pkg.types, nil
+	}
+
+	if from, ok := l.fallback.(types.ImporterFrom); ok {
+		return from.ImportFrom(importPath, dir, mode)
+	}
+	return l.fallback.Import(importPath)
+}
+
+// checkLibrary parses and type-checks the library files of a package,
+// keeping their syntax errors with it
+func (l *GoLoader) checkLibrary(importPath string, libFiles []string) *goPackage {
+	parsed := &ParseResult{Errors: make([]ParseError, 0)}
+	pkg := l.check(importPath, l.parseFiles(libFiles, parsed))
+	pkg.errors = parsed.Errors
+	return pkg
+}
+
+// check type-checks a set of files as one package. Only the files of one
+// build variant are checked together; the others keep their syntax-only view.
+func (l *GoLoader) check(importPath string, sources []goSource) *goPackage {
+	target := l.target
+	if target == nil {
+		target = &BuildTarget{}
+	}
+	files := make([]*ast.File, 0, len(sources))
+	for _, src := range sources {
+		// A recovered file mixes ASTs of several parses, which the checker cannot take
+		if target.Matches(src.constraint) && !src.recovered {
+			files = append(files, src.file)
+		}
+	}
+
+	info := &types.Info{
+		Types:      make(map[ast.Expr]types.TypeAndValue),
+		Defs:       make(map[*ast.Ident]types.Object),
+		Uses:       make(map[*ast.Ident]types.Object),
+		Selections: make(map[*ast.SelectorExpr]*types.Selection),
+	}
+	conf := types.Config{
+		Importer:    l,
+		FakeImportC: true,
+		Error:       func(error) {}, // Keep going; partial information is still useful
+	}
+	pkg, _ := conf.Check(importPath, l.fset, files, info)
+
+	return &goPackage{
+		path:    importPath,
+		sources: sources,
+		types:   pkg,
+		info:    info,
+	}
+}
+
+// distinguishVariants gives the symbols declared in several files of a
+// package, build variants such as F in f_linux.go and f_windows.go, IDs
+// naming their file the way assembly functions do: "pkg.F@f_linux.go"
+func distinguishVariants(elements []CodeElement) {
+	files := make(map[string]map[string]bool)
+	for _, el := range elements {
+		if files[el.ID] == nil {
+			files[el.ID] = make(map[string]bool)
+		}
+		files[el.ID][el.File] = true
+	}
+	for i := range elements {
+		el := &elements[i]
+		if len(files[el.ID]) > 1 {
+			el.ID += "@" + filepath.Base(el.File)
+			el.Hash = goHash(el)
+		}
+	}
+}
+
+// extract runs the element extraction over the given files of a checked package
+func (l *GoLoader) extract(pkg *goPackage, sources []goSource) []CodeElement {
+	elements := make([]CodeElement, 0)
+	for _, src := range sources {
+		relPath, err := filepath.Rel(l.root, src.path)
+		if err != nil {
+			relPath = src.path
+		}
+		f := &goFile{
+			fset:       l.fset,
+			path:       relPath,
+			importPath: pkg.path,
+			module:     l.module(filepath.Dir(src.path)).Path,
+			content:    string(src.content),
+			info:       pkg.info,
+			pkg:        pkg.types,
+		}
+		elements = append(elements, l.parser.parseFile(f, src.file)...)
+	}
+	l.recordTypeNames(pkg, elements)
+	return elements
+}
+
+// packageElement summarises a library package: import path, package
+// documentation (preferably from doc.go), files and exported symbol count
+func (l *GoLoader) packageElement(importPath string, pkg *goPackage, elements []CodeElement) CodeElement {
+	docSource := pkg.sources[0]
+	for _, src := range pkg.sources {
+		if src.file.Doc != nil && (docSource.file.Doc == nil || filepath.Base(src.path) == "doc.go") {
+			docSource = src
+		}
+	}
+
+	files := make([]string, len(pkg.sources))
+	for i, src := range pkg.sources {
+		files[i], _ = filepath.Rel(l.root, src.path)
+	}
+
+	exported := 0
+	if pkg.types != nil {
+		for _, name := range pkg.types.Scope().Names() {
+			if ast.IsExported(name) {
+				exported++
+			}
+		}
+	} else {
+		for _, el := range elements {
+			if el.Exports {
+				exported++
+			}
+		}
+	}
+
+	docFile, _ := filepath.Rel(l.root, docSource.path)
+	name := docSource.file.Name
+	start := name.Pos()
+	if docSource.file.Doc != nil {
+		start = docSource.file.Doc.Pos()
+	}
+	body := string(docSource.content[l.fset.Position(start).Offset:l.fset.Position(name.End()).Offset])
+
+	return CodeElement{
+		Type:            TypePackage,
+		Name:            name.Name,
+		ID:              importPath,
+		File:            docFile,
+		Line:            l.fset.Position(docSource.file.Package).Line,
+		EndLine:         l.fset.Position(name.End()).Line,
+		Hash:            HashCode(importPath + "\n" + body),
+		Body:            body,
+		Docstring:       l.parser.extractDocstring(docSource.file.Doc),
+		ImportPath:      importPath,
+		Module:          l.module(filepath.Dir(docSource.path)).Path,
+		Files:           files,
+		ExportedSymbols: exported,
+		Language:        "go",
+		IndexedAt:       time.Now(),
+	}
+}
+
+// parseFiles parses files, recording failures in result when given
+func (l *GoLoader) parseFiles(paths []string, result *ParseResult) []goSource {
+	sources := make([]goSource, 0, len(paths))
+	for _, p := range paths {
+		relPath, relErr := filepath.Rel(l.root, p)
+		if relErr != nil {
+			relPath = p
+		}
+
+		content, err := os.ReadFile(p)
+		if err != nil {
+			if result != nil {
+				result.Errors = append(result.Errors, ParseError{File: relPath, Message: err.Error()})
+			}
+			continue
+		}
+
+		// Files mid-edit still contribute every declaration that parsed
+		file, errs, recovered := parseGoFile(l.fset, p, relPath, content)
+		if result != nil {
+			result.Errors = append(result.Errors, errs...)
+		}
+		if file == nil {
+			continue
+		}
+
+		expr := goBuildConstraint(p, file)
+		if l.target != nil && !l.target.Matches(expr) {
+			continue
+		}
+
+		sources = append(sources, goSource{path: p, content: content, file: file, constraint: expr, recovered: recovered})
+	}
+	return sources
+}
+
+// listGoFiles lists the Go files of dir, split into library and test files
+func (l *GoLoader) listGoFiles(dir string) ([]string, []string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	libFiles := make([]string, 0)
+	testFiles := make([]string, 0)
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || filepath.Ext(name) != ".go" {
+			continue
+		}
+		if strings.HasSuffix(name, "_test.go") {
+			testFiles = append(testFiles, filepath.Join(dir, name))
+		} else {
+			libFiles = append(libFiles, filepath.Join(dir, name))
+		}
+	}
+	sort.Strings(libFiles)
+	sort.Strings(testFiles)
+	return libFiles, testFiles, nil
+}
+
+// module returns the innermost module containing dir (the zero GoModule if none does)
+func (l *GoLoader) module(dir string) GoModule {
+	for _, m := range l.modules {
+		if dir == m.Dir || strings.HasPrefix(dir, m.Dir+string(filepath.Separator)) {
+			return m
+		}
+	}
+	return GoModule{}
+}
+
+// importPath derives the import path of a directory from its module
+func (l *GoLoader) importPath(dir string) string {
+	m := l.module(dir)
+	if m.Path == "" {
+		rel, err := filepath.Rel(l.root, dir)
+		if err != nil || rel == "." {
+			return filepath.Base(dir)
+		}
+		return filepath.ToSlash(rel)
+	}
+	rel, err := filepath.Rel(m.Dir, dir)
+	if err != nil || rel == "." {
+		return m.Path
+	}
+	return path.Join(m.Path, filepath.ToSlash(rel))
+}
+
+// moduleDir reports whether importPath belongs to one of the loaded modules and returns its directory
+func (l *GoLoader) moduleDir(importPath string) (string, bool) {
+	var best GoModule
+	for _, m := range l.modules {
+		if (importPath == m.Path || strings.HasPrefix(importPath, m.Path+"/")) && len(m.Path) > len(best.Path) {
+			best = m
+		}
+	}
+	if best.Path == "" {
+		return "", false
+	}
+	rel := strings.TrimPrefix(strings.TrimPrefix(importPath, best.Path), "/")
+	dir := filepath.Join(best.Dir, filepath.FromSlash(rel))
+	// Directories of nested modules belong to those modules, not this one
+	if l.module(dir).Dir != best.Dir {
+		return "", false
+	}
+	return dir, true
+}
@@ -0,0 +1,126 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeTree writes files (relative path -> content) under a temporary root
+func writeTree(t *testing.T, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for path, content := range files {
+		full := filepath.Join(root, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+// loadDir loads one package directory of a tree written by writeTree
+func loadDir(t *testing.T, root, dir string) *ParseResult {
+	t.Helper()
+	result, err := NewGoLoader(root, nil).LoadDir(filepath.Join(root, dir))
+	if err != nil {
+		t.Fatalf("LoadDir(%s): %v", dir, err)
+	}
+	return result
+}
+
+func TestGoLoaderUniqueIDs(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  []string // IDs that must each appear exactly once
+		none  []string // IDs that must not appear
+	}{
+		{
+			name: "build variants with identical bodies",
+			files: map[string]string{
+				"s_linux.go":   "package s\n\nfunc Same() int { return 1 }\n",
+				"s_windows.go": "package s\n\nfunc Same() int { return 1 }\n",
+			},
+			want: []string{"example.com/s.Same@s_linux.go", "example.com/s.Same@s_windows.go"},
+		},
+		{
+			name: "single declaration keeps its plain ID",
+			files: map[string]string{
+				"s_linux.go": "package s\n\nfunc Only() int { return 1 }\n",
+			},
+			want: []string{"example.com/s.Only"},
+		},
+		{
+			name: "function-local types",
+			files: map[string]string{
+				"s.go": "package s\n\nfunc A() { type local struct{}; _ = local{} }\n\nfunc B() { type local int; _ = local(0) }\n",
+			},
+			want: []string{"example.com/s.A", "example.com/s.B"},
+			none: []string{"example.com/s.local"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := map[string]string{"go.mod": "module example.com/s\n\ngo 1.22\n"}
+			for path, content := range tt.files {
+				files[path] = content
+			}
+			result := loadDir(t, writeTree(t, files), ".")
+
+			ids := make(map[string]int)
+			hashes := make(map[string]string)
+			for _, el := range result.Elements {
+				if ids[el.ID]++; ids[el.ID] == 2 {
+					t.Errorf("duplicate ID %s", el.ID)
+				}
+				if other, ok := hashes[el.Hash]; ok {
+					t.Errorf("%s and %s share hash %s", other, el.ID, el.Hash)
+				}
+				hashes[el.Hash] = el.ID
+			}
+			for _, id := range tt.want {
+				if ids[id] != 1 {
+					t.Errorf("ID %s appears %d times; IDs: %v", id, ids[id], ids)
+				}
+			}
+			for _, id := range tt.none {
+				if ids[id] > 0 {
+					t.Errorf("ID %s should not be indexed", id)
+				}
+			}
+		})
+	}
+}
+
+func TestGoLoaderBuildTargetFiles(t *testing.T) {
+	tests := []struct {
+		target *BuildTarget
+		files  int
+	}{
+		{nil, 3},
+		{&BuildTarget{GOOS: "linux"}, 2},
+		{&BuildTarget{GOOS: "windows"}, 2},
+		{&BuildTarget{GOOS: "darwin"}, 1},
+	}
+	root := writeTree(t, map[string]string{
+		"go.mod":       "module example.com/s\n\ngo 1.22\n",
+		"s_linux.go":   "package s\n\nfunc Same() int { return 1 }\n",
+		"s_windows.go": "package s\n\nfunc Same() int { return 1 }\n",
+		"s_test.go":    "package s\n",
+	})
+	for _, tt := range tests {
+		loader := NewGoLoader(root, nil)
+		loader.SetBuildTarget(tt.target)
+		result, err := loader.LoadDir(root)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Files != tt.files {
+			t.Errorf("target %+v: loaded %d files, want %d", tt.target, result.Files, tt.files)
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package parser
+
+import (
+	"go/ast"
+	"go/token"
+)
+
+// extractMetrics computes complexity and size metrics for a function
+// declaration or literal spanning start to end
+func (p *GoParser) extractMetrics(body *ast.BlockStmt, start, end token.Pos, params []Parameter, f *goFile) *Metrics {
+	metrics := &Metrics{
+		Cyclomatic: 1,
+		Params:     len(params),
+		Lines:      f.fset.Position(end).Line - f.fset.Position(start).Line + 1,
+	}
+	if body == nil {
+		return metrics
+	}
+
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch t := n.(type) {
+		case *ast.IfStmt, *ast.ForStmt, *ast.RangeStmt:
+			metrics.Cyclomatic++
+		case *ast.CaseClause:
+			if t.List != nil { // default does not add a path
+				metrics.Cyclomatic++
+			}
+		case *ast.CommClause:
+			if t.Comm != nil {
+				metrics.Cyclomatic++
+			}
+		case *ast.BinaryExpr:
+			if t.Op == token.LAND || t.Op == token.LOR {
+				metrics.Cyclomatic++
+			}
+		}
+
+		switch n.(type) {
+		case nil, *ast.BlockStmt, *ast.CaseClause, *ast.CommClause, *ast.EmptyStmt:
+		case ast.Stmt:
+			metrics.Statements++
+		}
+		return true
+	})
+
+	c := &cognitive{}
+	c.stmts(body.List, 0)
+	metrics.Cognitive = c.score
+	metrics.MaxNesting = c.maxNesting
+
+	return metrics
+}
+
+// cognitive computes cognitive complexity as defined by SonarSource (without
+// the increment for recursion): flow breaks cost one, plus their nesting depth
+// for structures that nest
+type cognitive struct {
+	score      int
+	maxNesting int
+}
+
+func (c *cognitive) stmts(list []ast.Stmt, nesting int) {
+	for _, stmt := range list {
+		c.stmt(stmt, nesting)
+	}
+}
+
+func (c *cognitive) nest(nesting int) int {
+	nesting++
+	if nesting > c.maxNesting {
+		c.maxNesting = nesting
+	}
+	return nesting
+}
+
+func (c *cognitive) stmt(stmt ast.Stmt, nesting int) {
+	switch t := stmt.(type) {
+	case *ast.IfStmt:
+		c.score += 1 + nesting
+		c.ifStmt(t, nesting)
+
+	case *ast.ForStmt:
+		c.score += 1 + nesting
+		c.expr(t.Cond, nesting)
+		c.stmts(t.Body.List, c.nest(nesting))
+
+	case *ast.RangeStmt:
+		c.score += 1 + nesting
+		c.expr(t.X, nesting)
+		c.stmts(t.Body.List, c.nest(nesting))
+
+	case *ast.SwitchStmt:
+		c.score += 1 + nesting
+		c.expr(t.Tag, nesting)
+		c.clauses(t.Body, c.nest(nesting))
+
+	case *ast.TypeSwitchStmt:
+		c.score += 1 + nesting
+		c.clauses(t.Body, c.nest(nesting))
+
+	case *ast.SelectStmt:
+		c.score += 1 + nesting
+		c.clauses(t.Body, c.nest(nesting))
+
+	case *ast.BranchStmt:
+		if t.Label != nil || t.Tok == token.GOTO {
+			c.score++
+		}
+
+	case *ast.LabeledStmt:
+		c.stmt(t.Stmt, nesting)
+
+	case *ast.BlockStmt:
+		c.stmts(t.List, nesting)
+
+	default:
+		// Conditions and closures inside plain statements
+		ast.Inspect(stmt, func(n ast.Node) bool {
+			if e, ok := n.(ast.Expr); ok {
+				c.expr(e, nesting)
+				return false
+			}
+			return true
+		})
+	}
+}
+
+// ifStmt scores an if statement's condition, body and else chain; "else if"
+// and "else" cost one each but no nesting
+func (c *cognitive) ifStmt(t *ast.IfStmt, nesting int) {
+	if t.Init != nil {
+		c.stmt(t.Init, nesting)
+	}
+	c.expr(t.Cond, nesting)
+	c.stmts(t.Body.List, c.nest(nesting))
+
+	switch els := t.Else.(type) {
+	case *ast.IfStmt:
+		c.score++
+		c.ifStmt(els, nesting)
+	case *ast.BlockStmt:
+		c.score++
+		c.stmts(els.List, c.nest(nesting))
+	}
+}
+
+func (c *cognitive) clauses(body *ast.BlockStmt, nesting int) {
+	for _, clause := range body.List {
+		switch cl := clause.(type) {
+		case *ast.CaseClause:
+			c.stmts(cl.Body, nesting)
+		case *ast.CommClause:
+			c.stmts(cl.Body, nesting)
+		}
+	}
+}
+
+// expr scores sequences of like boolean operators (a && b && c costs one,
+// a && b || c two) and the bodies of function literals, which nest
+func (c *cognitive) expr(expr ast.Expr, nesting int) {
+	if expr == nil {
+		return
+	}
+	ast.Inspect(expr, func(n ast.Node) bool {
+		switch t := n.(type) {
+		case *ast.FuncLit:
+			c.stmts(t.Body.List, c.nest(nesting))
+			return false
+		case *ast.BinaryExpr:
+			if t.Op == token.LAND || t.Op == token.LOR {
+				ops := logicalOps(t, nil)
+				for i, op := range ops {
+					if i == 0 || op != ops[i-1] {
+						c.score++
+					}
+				}
+				// Operands may still hold closures
+				c.logicalOperands(t, nesting)
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// logicalOps lists the operators of a chain of && and || in source order
+func logicalOps(expr ast.Expr, ops []token.Token) []token.Token {
+	e, ok := unparen(expr).(*ast.BinaryExpr)
+	if !ok || (e.Op != token.LAND && e.Op != token.LOR) {
+		return ops
+	}
+	ops = logicalOps(e.X, ops)
+	ops = append(ops, e.Op)
+	return logicalOps(e.Y, ops)
+}
+
+// logicalOperands scores the non-boolean-operator operands of a chain
+func (c *cognitive) logicalOperands(expr ast.Expr, nesting int) {
+	e, ok := unparen(expr).(*ast.BinaryExpr)
+	if !ok || (e.Op != token.LAND && e.Op != token.LOR) {
+		c.expr(expr, nesting)
+		return
+	}
+	c.logicalOperands(e.X, nesting)
+	c.logicalOperands(e.Y, nesting)
+}
+
+// unparen strips parentheses from an expression
+func unparen(expr ast.Expr) ast.Expr {
+	for {
+		paren, ok := expr.(*ast.ParenExpr)
+		if !ok {
+			return expr
+		}
+		expr = paren.X
+	}
+}
@@ -2,11 +2,14 @@ package parser
 
 import (
 	"go/ast"
-	"go/parser"
+	"go/scanner"
 	"go/token"
+	"go/types"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
+	"unicode"
 )
 
 // GoParser parses Go source code
@@ -31,109 +34,259 @@ func (p *GoParser) Parse(filePath string, content []byte) (*ParseResult, error)
 	}
 
 	fset := token.NewFileSet()
-	file, err := parser.ParseFile(fset, filePath, content, parser.ParseComments)
-	if err != nil {
-		result.Errors = append(result.Errors, ParseError{
-			Message: err.Error(),
-		})
+	file, errs, _ := parseGoFile(fset, filePath, filePath, content)
+	result.Errors = append(result.Errors, errs...)
+	if file == nil {
 		return result, nil
 	}
 
+	f := &goFile{
+		fset:    fset,
+		path:    filePath,
+		content: string(content),
+	}
+	result.Elements = p.parseFile(f, file)
+
+	return result, nil
+}
+
+// goParseErrors converts a go/parser error into positioned ParseErrors
+func goParseErrors(err error, filePath string) []ParseError {
+	list, ok := err.(scanner.ErrorList)
+	if !ok {
+		return []ParseError{{File: filePath, Message: err.Error()}}
+	}
+
+	errs := make([]ParseError, 0, len(list))
+	for _, e := range list {
+		errs = append(errs, ParseError{
+			File:    filePath,
+			Message: e.Msg,
+			Line:    e.Pos.Line,
+			Column:  e.Pos.Column,
+		})
+	}
+	return errs
+}
+
+// goFile carries the per-file state shared by the extraction helpers.
+// importPath, info and pkg are only set when the file was loaded as part
+// of a package.
+type goFile struct {
+	fset       *token.FileSet
+	path       string
+	importPath string
+	module     string
+	content    string
+	imports    []string
+	linknames  map[string]string // Local name -> target of //go:linkname directives
+	info       *types.Info
+	pkg        *types.Package
+}
+
+// symbolID qualifies a package-level symbol ("F", "(*T).M") with the import path
+func (f *goFile) symbolID(symbol string) string {
+	if f.importPath == "" {
+		return symbol
+	}
+	return f.importPath + "." + symbol
+}
+
+// qualify renders package qualifiers by package name, omitting the file's own package
+func (f *goFile) qualify(pkg *types.Package) string {
+	if pkg == f.pkg {
+		return ""
+	}
+	return pkg.Name()
+}
+
+// parseFile walks a parsed file and extracts its elements
+func (p *GoParser) parseFile(f *goFile, file *ast.File) []CodeElement {
+	elements := make([]CodeElement, 0)
+
 	// Extract imports
-	imports := p.extractImports(file)
+	f.imports = p.extractImports(file)
+	f.linknames = goLinknames(file)
 
-	// Walk AST
-	ast.Inspect(file, func(n ast.Node) bool {
-		switch node := n.(type) {
+	// Package-level constants and variables (local ones are not worth indexing)
+	for _, decl := range file.Decls {
+		if gen, ok := decl.(*ast.GenDecl); ok && (gen.Tok == token.CONST || gen.Tok == token.VAR) {
+			elements = append(elements, p.extractValues(gen, f)...)
+		}
+	}
+
+	// Functions and types, package-level only: a type declared in a function
+	// body is local to it and has no package-level ID
+	for _, decl := range file.Decls {
+		switch node := decl.(type) {
 		case *ast.FuncDecl:
-			if element := p.extractFunction(node, fset, filePath, string(content), imports); element != nil {
-				result.Elements = append(result.Elements, *element)
+			if element := p.extractFunction(node, f); element != nil {
+				elements = append(elements, *element)
 			}
 		case *ast.GenDecl:
 			// Handle type declarations (struct, interface, type alias)
 			for _, spec := range node.Specs {
 				switch s := spec.(type) {
 				case *ast.TypeSpec:
-					if element := p.extractType(s, node, fset, filePath, string(content)); element != nil {
-						result.Elements = append(result.Elements, *element)
+					if element := p.extractType(s, node, f); element != nil {
+						elements = append(elements, *element)
 					}
 				}
 			}
 		}
-		return true
-	})
+	}
 
-	return result, nil
+	elements = append(elements, p.extractFuncLits(file, f)...)
+	elements = append(elements, p.extractCgo(file, f)...)
+
+	constraint := ""
+	if expr := goBuildConstraint(f.path, file); expr != nil {
+		constraint = expr.String()
+	}
+	for i := range elements {
+		el := &elements[i]
+		el.ImportPath = f.importPath
+		el.Module = f.module
+		el.BuildConstraint = constraint
+		el.Hash = goHash(el)
+	}
+
+	return elements
+}
+
+// goHash hashes a Go element. With the ID and build constraint in the hash,
+// identical bodies (of other symbols, or of build variants) no longer
+// deduplicate each other.
+func goHash(el *CodeElement) string {
+	return HashCode(el.ID + "\n" + el.BuildConstraint + "\n" + el.Body)
 }
 
 // extractFunction extracts function/method information
-func (p *GoParser) extractFunction(node *ast.FuncDecl, fset *token.FileSet, filePath, content string, imports []string) *CodeElement {
+func (p *GoParser) extractFunction(node *ast.FuncDecl, f *goFile) *CodeElement {
 	if node.Name == nil {
 		return nil
 	}
 
-	pos := fset.Position(node.Pos())
-	endPos := fset.Position(node.End())
+	pos := f.fset.Position(node.Pos())
+	endPos := f.fset.Position(node.End())
 
-	body := p.extractNodeBody(node.Pos(), node.End(), content)
+	body := p.extractNodeBody(node.Pos(), node.End(), f)
 	docstring := p.extractDocstring(node.Doc)
 
-	params := p.extractParams(node.Type.Params)
-	returns := p.extractReturns(node.Type.Results)
+	params := p.extractParams(node.Type.Params, f)
+	returns := p.extractReturns(node.Type.Results, f)
+	typeParams := p.extractParams(node.Type.TypeParams, f)
 
 	// Check if this is a method (has receiver)
 	isMethod := node.Recv != nil
 	name := node.Name.Name
+	symbol := name
 	if isMethod && node.Recv.NumFields() > 0 {
 		// Include receiver type in name for methods
-		recvType := p.getReceiverType(node.Recv.List[0].Type)
+		recvExpr := node.Recv.List[0].Type
+		recvType := p.getReceiverType(recvExpr)
 		name = recvType + "." + name
+		_, pointer := recvExpr.(*ast.StarExpr)
+		symbol = methodSymbol(recvType, node.Name.Name, pointer)
 	}
 
-	return &CodeElement{
-		Type:      TypeFunction,
-		Name:      name,
-		File:      filePath,
-		Line:      pos.Line,
-		EndLine:   endPos.Line,
-		Hash:      HashCode(body),
-		Params:    params,
-		Returns:   returns,
-		Body:      body,
-		Docstring: docstring,
-		Imports:   imports,
-		Exports:   ast.IsExported(node.Name.Name),
-		Language:  "go",
-		IndexedAt: time.Now(),
+	el := &CodeElement{
+		Type:       p.functionKind(node, params, f),
+		Name:       name,
+		ID:         f.symbolID(symbol),
+		File:       f.path,
+		Line:       pos.Line,
+		EndLine:    endPos.Line,
+		Hash:       HashCode(body),
+		TypeParams: typeParams,
+		Params:     params,
+		Returns:    returns,
+		Calls:      p.extractCalls(node.Body, f),
+		Metrics:    p.extractMetrics(node.Body, node.Pos(), node.End(), params, f),
+		Body:       body,
+		Docstring:  docstring,
+		Imports:    f.imports,
+		Exports:    ast.IsExported(node.Name.Name),
+		Language:   "go",
+		IndexedAt:  time.Now(),
 	}
+
+	// A declaration without a body is implemented in assembly (linked later)
+	// or pulled in from another package with //go:linkname
+	if target, ok := f.linknames[node.Name.Name]; ok && node.Body == nil && !isMethod {
+		el.ImplementedBy = []string{target}
+	}
+	return el
+}
+
+// functionKind tells test, benchmark, fuzz and example functions in _test.go
+// files apart from plain functions, using the rules of go test
+func (p *GoParser) functionKind(node *ast.FuncDecl, params []Parameter, f *goFile) ElementType {
+	if node.Recv != nil || node.Type.TypeParams != nil || !strings.HasSuffix(f.path, "_test.go") {
+		return TypeFunction
+	}
+
+	kinds := []struct {
+		prefix string
+		param  string
+		kind   ElementType
+	}{
+		{"Test", "*testing.T", TypeTest},
+		{"Benchmark", "*testing.B", TypeBenchmark},
+		{"Fuzz", "*testing.F", TypeFuzz},
+		{"Example", "", TypeExample},
+	}
+
+	name := node.Name.Name
+	for _, k := range kinds {
+		rest, ok := strings.CutPrefix(name, k.prefix)
+		if !ok {
+			continue
+		}
+		// TestMain and a lowercase continuation (Testify) are not tests
+		if name == "TestMain" || (rest != "" && unicode.IsLower([]rune(rest)[0])) {
+			return TypeFunction
+		}
+		if k.param == "" {
+			if len(params) == 0 && node.Type.Results == nil {
+				return k.kind
+			}
+		} else if len(params) == 1 && params[0].Type == k.param {
+			return k.kind
+		}
+		return TypeFunction
+	}
+	return TypeFunction
 }
 
 // extractType extracts struct, interface, or type alias
-func (p *GoParser) extractType(spec *ast.TypeSpec, decl *ast.GenDecl, fset *token.FileSet, filePath, content string) *CodeElement {
-	pos := fset.Position(decl.Pos())
-	endPos := fset.Position(decl.End())
+func (p *GoParser) extractType(spec *ast.TypeSpec, decl *ast.GenDecl, f *goFile) *CodeElement {
+	pos := f.fset.Position(decl.Pos())
+	endPos := f.fset.Position(decl.End())
 
-	body := p.extractNodeBody(decl.Pos(), decl.End(), content)
+	body := p.extractNodeBody(decl.Pos(), decl.End(), f)
 	docstring := p.extractDocstring(decl.Doc)
 
 	element := &CodeElement{
-		Name:      spec.Name.Name,
-		File:      filePath,
-		Line:      pos.Line,
-		EndLine:   endPos.Line,
-		Hash:      HashCode(body),
-		Body:      body,
-		Docstring: docstring,
-		Exports:   ast.IsExported(spec.Name.Name),
-		Language:  "go",
-		IndexedAt: time.Now(),
+		Name:       spec.Name.Name,
+		ID:         f.symbolID(spec.Name.Name),
+		File:       f.path,
+		Line:       pos.Line,
+		EndLine:    endPos.Line,
+		Hash:       HashCode(body),
+		TypeParams: p.extractParams(spec.TypeParams, f),
+		Body:       body,
+		Docstring:  docstring,
+		Exports:    ast.IsExported(spec.Name.Name),
+		Language:   "go",
+		IndexedAt:  time.Now(),
 	}
 
 	switch typeNode := spec.Type.(type) {
 	case *ast.StructType:
 		element.Type = TypeStruct
-		element.Fields = p.extractFields(typeNode.Fields)
-		element.Methods = []string{} // Will be filled separately
+		element.Fields = p.extractFields(typeNode.Fields, f)
+		element.Methods = []string{} // Filled by GoLoader.Link
 
 	case *ast.InterfaceType:
 		element.Type = TypeInterface
@@ -146,15 +299,178 @@ func (p *GoParser) extractType(spec *ast.TypeSpec, decl *ast.GenDecl, fset *toke
 	return element
 }
 
-// extractParams extracts function parameters
-func (p *GoParser) extractParams(fields *ast.FieldList) []Parameter {
+// extractValues extracts the constants or variables of a const/var declaration.
+// A const group that uses iota, or whose constants share a named type, is an
+// enum of that type.
+func (p *GoParser) extractValues(decl *ast.GenDecl, f *goFile) []CodeElement {
+	elements := make([]CodeElement, 0)
+
+	elemType := TypeVariable
+	if decl.Tok == token.CONST {
+		elemType = TypeConstant
+	}
+	enumType, isEnum := p.enumTypeName(decl)
+
+	// Constant specs without values repeat the previous type and expressions
+	var lastType ast.Expr
+	var lastValues []ast.Expr
+
+	for _, spec := range decl.Specs {
+		vs, ok := spec.(*ast.ValueSpec)
+		if !ok {
+			continue
+		}
+		typeExpr, values := vs.Type, vs.Values
+		implicit := decl.Tok == token.CONST && typeExpr == nil && len(values) == 0
+		if implicit {
+			typeExpr, values = lastType, lastValues
+		} else {
+			lastType, lastValues = typeExpr, values
+		}
+
+		start, end := vs.Pos(), vs.End()
+		docstring := p.extractDocstring(vs.Doc)
+		if !decl.Lparen.IsValid() {
+			// Ungrouped declaration: the doc comment and keyword sit on the decl
+			start, end = decl.Pos(), decl.End()
+			docstring = p.extractDocstring(decl.Doc)
+		}
+		if docstring == "" {
+			docstring = p.extractDocstring(vs.Comment)
+		}
+		body := p.extractNodeBody(start, end, f)
+		pos := f.fset.Position(start)
+		endPos := f.fset.Position(end)
+
+		for i, name := range vs.Names {
+			if name.Name == "_" {
+				continue
+			}
+			if i < len(vs.Values) {
+				if isFuncLit(vs.Values[i]) {
+					continue // Indexed as a function by extractFuncLits
+				}
+			}
+
+			element := CodeElement{
+				Type:      elemType,
+				Name:      name.Name,
+				ID:        f.symbolID(name.Name),
+				File:      f.path,
+				Line:      pos.Line,
+				EndLine:   endPos.Line,
+				Hash:      HashCode(body),
+				Body:      body,
+				Docstring: docstring,
+				Exports:   ast.IsExported(name.Name),
+				Language:  "go",
+				IndexedAt: time.Now(),
+			}
+
+			if typeExpr != nil {
+				element.ValueType = p.typeString(typeExpr, f)
+			}
+			if implicit {
+				// A repeated iota expression says little; the computed value says more
+				element.Value = p.constValue(name, f)
+			}
+			if element.Value == "" && i < len(values) {
+				element.Value = types.ExprString(values[i])
+			}
+
+			if obj := p.definedObject(name, f); obj != nil {
+				if element.ValueType == "" {
+					element.ValueType = types.TypeString(obj.Type(), f.qualify)
+				}
+				// The checker also knows the type of specs like "A = Kind(iota)"
+				if named, ok := obj.Type().(*types.Named); ok && isEnum && named.Obj().Pkg() == f.pkg {
+					enumType = named.Obj().Name()
+				}
+			}
+			if isEnum {
+				element.EnumOf = enumType
+			}
+
+			elements = append(elements, element)
+		}
+	}
+
+	return elements
+}
+
+// enumTypeName reports whether a const group is enum-like (it uses iota or
+// its constants share a named type) and the named type it belongs to, if known
+func (p *GoParser) enumTypeName(decl *ast.GenDecl) (string, bool) {
+	if decl.Tok != token.CONST || len(decl.Specs) < 2 {
+		return "", false
+	}
+
+	typeName := ""
+	typedSpecs := 0
+	usesIota := false
+	for _, spec := range decl.Specs {
+		vs, ok := spec.(*ast.ValueSpec)
+		if !ok {
+			continue
+		}
+		if ident, ok := vs.Type.(*ast.Ident); ok {
+			if typeName != "" && typeName != ident.Name {
+				return "", false
+			}
+			typeName = ident.Name
+			typedSpecs++
+		}
+		for _, value := range vs.Values {
+			ast.Inspect(value, func(n ast.Node) bool {
+				if ident, ok := n.(*ast.Ident); ok && ident.Name == "iota" {
+					usesIota = true
+				}
+				return !usesIota
+			})
+		}
+	}
+
+	if isPredeclaredType(typeName) {
+		typeName = ""
+	}
+	if usesIota || (typeName != "" && typedSpecs > 1) {
+		return typeName, true
+	}
+	return "", false
+}
+
+// definedObject returns the type-checker object declared by name, if the file was type-checked
+func (p *GoParser) definedObject(name *ast.Ident, f *goFile) types.Object {
+	if f.info == nil {
+		return nil
+	}
+	return f.info.Defs[name]
+}
+
+// constValue returns the computed value of a constant, if the file was type-checked
+func (p *GoParser) constValue(name *ast.Ident, f *goFile) string {
+	if c, ok := p.definedObject(name, f).(*types.Const); ok {
+		return c.Val().ExactString()
+	}
+	return ""
+}
+
+// isPredeclaredType reports whether name is a builtin type such as int or string
+func isPredeclaredType(name string) bool {
+	obj := types.Universe.Lookup(name)
+	_, ok := obj.(*types.TypeName)
+	return ok
+}
+
+// extractParams extracts function parameters (or type parameters with their constraints)
+func (p *GoParser) extractParams(fields *ast.FieldList, f *goFile) []Parameter {
 	if fields == nil {
 		return []Parameter{}
 	}
 
 	params := make([]Parameter, 0)
 	for _, field := range fields.List {
-		typeStr := p.exprToString(field.Type)
+		typeStr := p.typeString(field.Type, f)
 
 		if len(field.Names) == 0 {
 			// Unnamed parameter
@@ -175,14 +491,14 @@ func (p *GoParser) extractParams(fields *ast.FieldList) []Parameter {
 }
 
 // extractReturns extracts return types
-func (p *GoParser) extractReturns(fields *ast.FieldList) string {
+func (p *GoParser) extractReturns(fields *ast.FieldList, f *goFile) string {
 	if fields == nil || len(fields.List) == 0 {
 		return ""
 	}
 
 	returns := make([]string, 0)
 	for _, field := range fields.List {
-		typeStr := p.exprToString(field.Type)
+		typeStr := p.typeString(field.Type, f)
 		if len(field.Names) > 0 {
 			for _, name := range field.Names {
 				returns = append(returns, name.Name+" "+typeStr)
@@ -198,21 +514,61 @@ func (p *GoParser) extractReturns(fields *ast.FieldList) string {
 	return "(" + strings.Join(returns, ", ") + ")"
 }
 
-// extractFields extracts struct fields
-func (p *GoParser) extractFields(fields *ast.FieldList) []string {
+// extractFields extracts struct fields, including embedded ones
+func (p *GoParser) extractFields(fields *ast.FieldList, f *goFile) []Field {
 	if fields == nil {
-		return []string{}
+		return []Field{}
 	}
 
-	fieldNames := make([]string, 0)
+	result := make([]Field, 0)
 	for _, field := range fields.List {
-		if len(field.Names) > 0 {
-			for _, name := range field.Names {
-				fieldNames = append(fieldNames, name.Name)
+		typeStr := p.typeString(field.Type, f)
+
+		tag := ""
+		if field.Tag != nil {
+			if unquoted, err := strconv.Unquote(field.Tag.Value); err == nil {
+				tag = unquoted
 			}
 		}
+
+		docstring := p.extractDocstring(field.Doc)
+		if docstring == "" {
+			docstring = p.extractDocstring(field.Comment)
+		}
+
+		base := Field{
+			Type:      typeStr,
+			Tag:       tag,
+			Tags:      ParseStructTag(tag),
+			Docstring: docstring,
+		}
+
+		if len(field.Names) == 0 {
+			// Embedded field: named after its type
+			base.Name = p.getReceiverType(field.Type)
+			if sel, ok := unstar(field.Type).(*ast.SelectorExpr); ok {
+				base.Name = sel.Sel.Name
+			}
+			base.Embedded = true
+			result = append(result, base)
+			continue
+		}
+
+		for _, name := range field.Names {
+			named := base
+			named.Name = name.Name
+			result = append(result, named)
+		}
 	}
-	return fieldNames
+	return result
+}
+
+// unstar strips a pointer from a type expression
+func unstar(expr ast.Expr) ast.Expr {
+	if star, ok := expr.(*ast.StarExpr); ok {
+		return star.X
+	}
+	return expr
 }
 
 // extractInterfaceMethods extracts interface method names
@@ -242,6 +598,20 @@ func (p *GoParser) extractImports(file *ast.File) []string {
 	return imports
 }
 
+// goLinknames collects the //go:linkname directives of a file
+func goLinknames(file *ast.File) map[string]string {
+	linknames := make(map[string]string)
+	for _, group := range file.Comments {
+		for _, c := range group.List {
+			fields := strings.Fields(c.Text)
+			if len(fields) == 3 && fields[0] == "//go:linkname" {
+				linknames[fields[1]] = fields[2]
+			}
+		}
+	}
+	return linknames
+}
+
 // extractDocstring extracts documentation comment
 func (p *GoParser) extractDocstring(doc *ast.CommentGroup) string {
 	if doc == nil {
@@ -251,16 +621,32 @@ func (p *GoParser) extractDocstring(doc *ast.CommentGroup) string {
 }
 
 // extractNodeBody extracts source code for a node
-func (p *GoParser) extractNodeBody(start, end token.Pos, content string) string {
+func (p *GoParser) extractNodeBody(start, end token.Pos, f *goFile) string {
 	if start == 0 || end == 0 {
 		return ""
 	}
-	startIdx := int(start) - 1
-	endIdx := int(end) - 1
-	if startIdx < 0 || endIdx > len(content) || startIdx >= endIdx {
+	startIdx := f.fset.Position(start).Offset
+	endIdx := f.fset.Position(end).Offset
+	if startIdx < 0 || endIdx > len(f.content) || startIdx >= endIdx {
 		return ""
 	}
-	return content[startIdx:endIdx]
+	return f.content[startIdx:endIdx]
+}
+
+// typeString renders a type expression, preferring the type checker's view when available
+func (p *GoParser) typeString(expr ast.Expr, f *goFile) string {
+	if ellipsis, ok := expr.(*ast.Ellipsis); ok {
+		return "..." + p.typeString(ellipsis.Elt, f)
+	}
+	if f.info != nil {
+		if tv, ok := f.info.Types[expr]; ok && tv.Type != nil {
+			// Unresolved imports degrade to "invalid type"; the syntax is more useful then
+			if s := types.TypeString(tv.Type, f.qualify); !strings.Contains(s, "invalid type") {
+				return s
+			}
+		}
+	}
+	return p.exprToString(expr)
 }
 
 // exprToString converts expression to string
@@ -280,8 +666,10 @@ func (p *GoParser) exprToString(expr ast.Expr) string {
 		return "interface{}"
 	case *ast.FuncType:
 		return "func"
-	default:
+	case nil:
 		return "unknown"
+	default:
+		return types.ExprString(expr)
 	}
 }
 
@@ -292,6 +680,10 @@ func (p *GoParser) getReceiverType(expr ast.Expr) string {
 		return t.Name
 	case *ast.StarExpr:
 		return p.getReceiverType(t.X)
+	case *ast.IndexExpr:
+		return p.getReceiverType(t.X)
+	case *ast.IndexListExpr:
+		return p.getReceiverType(t.X)
 	default:
 		return "unknown"
 	}
@@ -0,0 +1,129 @@
+package parser
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/token"
+)
+
+// parseGoFile parses a Go file, reporting every syntax error with its position.
+// When the file does not parse, its declarations are recovered one at a time
+// (see recoverGoFile); recovered is set in that case. The file is nil when not
+// even the package clause parsed.
+func parseGoFile(fset *token.FileSet, path, relPath string, content []byte) (file *ast.File, errs []ParseError, recovered bool) {
+	file, err := parser.ParseFile(fset, path, content, parser.ParseComments|parser.AllErrors)
+	if err != nil {
+		errs = goParseErrors(err, relPath)
+	}
+	if file == nil || file.Name == nil || file.Name.Name == "" {
+		return nil, errs, false
+	}
+	if err != nil {
+		return recoverGoFile(fset, path, content, file), errs, true
+	}
+	return file, nil, false
+}
+
+// recoverGoFile re-parses a file that failed to parse one top-level
+// declaration at a time, so that a single broken declaration (typically an
+// unbalanced brace mid-edit) does not take the rest of the file with it.
+// Each pass blanks out everything but the file header and one declaration,
+// keeping newlines, so positions stay those of the original source.
+func recoverGoFile(fset *token.FileSet, path string, content []byte, partial *ast.File) *ast.File {
+	starts := declStarts(content)
+	if len(starts) == 0 {
+		return partial
+	}
+	header := content[:starts[0]]
+
+	recovered := &ast.File{
+		Doc:      partial.Doc,
+		Package:  partial.Package,
+		Name:     partial.Name,
+		Imports:  partial.Imports,
+		Comments: partial.Comments,
+	}
+	for _, decl := range partial.Decls {
+		if gen, ok := decl.(*ast.GenDecl); ok && gen.Tok == token.IMPORT {
+			recovered.Decls = append(recovered.Decls, decl)
+		}
+	}
+
+	for i, start := range starts {
+		end := len(content)
+		if i+1 < len(starts) {
+			end = starts[i+1]
+		}
+
+		masked := make([]byte, len(content))
+		for j, b := range content {
+			switch {
+			case j < len(header), j >= start && j < end, b == '\n':
+				masked[j] = b
+			default:
+				masked[j] = ' '
+			}
+		}
+
+		file, err := parser.ParseFile(fset, path, masked, parser.ParseComments)
+		if err != nil {
+			continue // This is the broken one
+		}
+		for _, decl := range file.Decls {
+			if gen, ok := decl.(*ast.GenDecl); ok && gen.Tok == token.IMPORT {
+				continue
+			}
+			recovered.Decls = append(recovered.Decls, decl)
+		}
+	}
+
+	return recovered
+}
+
+// declStarts returns the offsets of top-level func/type/var/const
+// declarations, including the comment lines directly above them
+func declStarts(content []byte) []int {
+	keywords := [][]byte{[]byte("func "), []byte("func("), []byte("type "), []byte("var "), []byte("const ")}
+
+	starts := make([]int, 0)
+	lineStart := 0
+	commentStart := -1
+	for lineStart < len(content) {
+		lineEnd := bytes.IndexByte(content[lineStart:], '\n')
+		if lineEnd < 0 {
+			lineEnd = len(content)
+		} else {
+			lineEnd += lineStart
+		}
+		line := content[lineStart:lineEnd]
+
+		switch {
+		case bytes.HasPrefix(line, []byte("//")):
+			if commentStart < 0 {
+				commentStart = lineStart
+			}
+		case hasAnyPrefix(line, keywords):
+			start := lineStart
+			if commentStart >= 0 {
+				start = commentStart
+			}
+			starts = append(starts, start)
+			commentStart = -1
+		default:
+			commentStart = -1
+		}
+
+		lineStart = lineEnd + 1
+	}
+	return starts
+}
+
+func hasAnyPrefix(line []byte, prefixes [][]byte) bool {
+	for _, prefix := range prefixes {
+		if bytes.HasPrefix(line, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,133 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ReadModulePath returns the module path declared in dir/go.mod, or "" if there is none
+func ReadModulePath(dir string) string {
+	data, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		return ""
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "module") {
+			continue
+		}
+		path := strings.TrimSpace(strings.TrimPrefix(line, "module"))
+		if i := strings.Index(path, "//"); i >= 0 {
+			path = strings.TrimSpace(path[:i])
+		}
+		if unquoted, err := strconv.Unquote(path); err == nil {
+			path = unquoted
+		}
+		return path
+	}
+	return ""
+}
+
+// GoModule is a module found under the indexed root
+type GoModule struct {
+	Path string // Module path from go.mod
+	Dir  string // Absolute directory holding go.mod
+}
+
+// ReadWorkspace returns the module directories listed by the use directives
+// of dir/go.work, or nil if there is no go.work
+func ReadWorkspace(dir string) []string {
+	data, err := os.ReadFile(filepath.Join(dir, "go.work"))
+	if err != nil {
+		return nil
+	}
+
+	dirs := make([]string, 0)
+	inUse := false
+	for _, line := range strings.Split(string(data), "\n") {
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+
+		switch {
+		case inUse && line == ")":
+			inUse = false
+			continue
+		case inUse:
+		case line == "use (":
+			inUse = true
+			continue
+		case strings.HasPrefix(line, "use "):
+			line = strings.TrimSpace(strings.TrimPrefix(line, "use "))
+		default:
+			continue
+		}
+
+		if line == "" {
+			continue
+		}
+		if unquoted, err := strconv.Unquote(line); err == nil {
+			line = unquoted
+		}
+		if !filepath.IsAbs(line) {
+			line = filepath.Join(dir, filepath.FromSlash(line))
+		}
+		dirs = append(dirs, filepath.Clean(line))
+	}
+	return dirs
+}
+
+// FindModules discovers the modules under root: those listed in root/go.work
+// and every nested go.mod. Vendored, testdata and hidden directories are skipped,
+// as the go command does, and so are those skip reports (by path relative to
+// root) when it is not nil: node_modules and build output are not walked.
+// The result is ordered deepest directory first.
+func FindModules(root string, skip func(relPath string) bool) []GoModule {
+	seen := make(map[string]bool)
+	modules := make([]GoModule, 0)
+	add := func(dir string) {
+		if seen[dir] {
+			return
+		}
+		seen[dir] = true
+		if path := ReadModulePath(dir); path != "" {
+			modules = append(modules, GoModule{Path: path, Dir: dir})
+		}
+	}
+
+	for _, dir := range ReadWorkspace(root) {
+		add(dir)
+	}
+
+	filepath.WalkDir(root, func(p string, d os.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return nil
+		}
+		name := d.Name()
+		if p == root {
+			add(p)
+			return nil
+		}
+		if name == "vendor" || name == "testdata" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") {
+			return filepath.SkipDir
+		}
+		if rel, err := filepath.Rel(root, p); err == nil && skip != nil && skip(rel) {
+			return filepath.SkipDir
+		}
+		add(p)
+		return nil
+	})
+
+	sort.Slice(modules, func(i, j int) bool {
+		if len(modules[i].Dir) != len(modules[j].Dir) {
+			return len(modules[i].Dir) > len(modules[j].Dir)
+		}
+		return modules[i].Dir < modules[j].Dir
+	})
+	return modules
+}
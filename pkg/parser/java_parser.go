@@ -0,0 +1,666 @@
+package parser
+
+import (
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// JavaParser parses Java source: the package, its types with their
+// hierarchy, members and annotations. Method bodies and initializers are
+// skipped as balanced token runs.
+type JavaParser struct{}
+
+// NewJavaParser creates a new Java parser
+func NewJavaParser() *JavaParser {
+	return &JavaParser{}
+}
+
+// SupportsFile checks if the parser supports this file
+func (p *JavaParser) SupportsFile(filePath string) bool {
+	return filepath.Ext(filePath) == ".java"
+}
+
+// Parse parses Java source and extracts its package, types and members
+func (p *JavaParser) Parse(filePath string, content []byte) (*ParseResult, error) {
+	src := string(content)
+	f := &javaFile{
+		tokenCursor: newTokenCursor(tokenizeJava(src), src, javaToken{srcToken: srcToken{pos: len(src), end: len(src)}}),
+		path:        filePath,
+	}
+	f.compilationUnit()
+
+	exported := 0
+	for _, el := range f.elements {
+		if el.Exports && el.Type != TypeFunction && !strings.Contains(el.Name, ".") {
+			exported++
+		}
+	}
+	for i := range f.elements {
+		el := &f.elements[i]
+		if el.Type == TypePackage {
+			el.ExportedSymbols = exported
+		}
+		el.Imports = f.imports
+		el.Hash = HashCode(el.ID + "\n" + el.Body)
+	}
+	return &ParseResult{
+		Elements: f.elements,
+		Errors:   make([]ParseError, 0),
+	}, nil
+}
+
+// javaToken is a token with its byte range; doc is the Javadoc before it
+type javaToken struct {
+	srcToken
+	doc string
+}
+
+// tokenizeJava splits Java source into tokens, dropping comments but
+// keeping each Javadoc comment on the token that follows it
+func tokenizeJava(src string) []javaToken {
+	tokens := make([]javaToken, 0, len(src)/4)
+	doc := ""
+	for pos := 0; pos < len(src); {
+		c := src[pos]
+		start := pos
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f':
+			pos++
+			continue
+		case strings.HasPrefix(src[pos:], "//"):
+			if end := strings.IndexByte(src[pos:], '\n'); end >= 0 {
+				pos += end
+			} else {
+				pos = len(src)
+			}
+			continue
+		case strings.HasPrefix(src[pos:], "/*"):
+			end := strings.Index(src[pos+2:], "*/")
+			if end < 0 {
+				end = len(src) - pos - 4
+			}
+			if comment := src[pos : pos+end+4]; strings.HasPrefix(comment, "/**") && comment != "/**/" {
+				doc = comment
+			}
+			pos += end + 4
+			continue
+		case strings.HasPrefix(src[pos:], `"""`): // Text block
+			end := strings.Index(src[pos+3:], `"""`)
+			for end > 0 && src[pos+3+end-1] == '\\' {
+				next := strings.Index(src[pos+3+end+1:], `"""`)
+				if next < 0 {
+					end = -1
+					break
+				}
+				end += next + 1
+			}
+			if end < 0 {
+				end = len(src) - pos - 6
+			}
+			pos += end + 6
+		case c == '"' || c == '\'':
+			pos++
+			for pos < len(src) && src[pos] != c && src[pos] != '\n' {
+				if src[pos] == '\\' {
+					pos++
+				}
+				pos++
+			}
+			pos++
+		case c >= '0' && c <= '9' || c == '.' && pos+1 < len(src) && isDigit(src[pos+1]):
+			for pos < len(src) && (isIdentByte(src[pos]) || src[pos] == '.') {
+				pos++
+			}
+		case c == '_' || c == '$' || c >= 0x80 || unicode.IsLetter(rune(c)):
+			for pos < len(src) {
+				r, size := utf8.DecodeRuneInString(src[pos:])
+				if r != '_' && r != '$' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+					break
+				}
+				pos += size
+			}
+		case strings.HasPrefix(src[pos:], "..."):
+			pos += 3
+		case strings.HasPrefix(src[pos:], "::") || strings.HasPrefix(src[pos:], "->"):
+			pos += 2
+		default:
+			pos++ // ">" stays single so that nested type arguments close
+		}
+		if pos > len(src) {
+			pos = len(src)
+		}
+		tokens = append(tokens, javaToken{srcToken: srcToken{text: src[start:pos], pos: start, end: pos}, doc: doc})
+		doc = ""
+	}
+	return tokens
+}
+
+// javaFile is the parsing state of one Java file
+type javaFile struct {
+	tokenCursor[javaToken]
+	path     string
+	pkg      string
+	imports  []string
+	elements []CodeElement
+}
+
+// javaModifiers are the modifiers that can precede a declaration
+var javaModifiers = map[string]bool{
+	"public": true, "protected": true, "private": true, "static": true, "final": true,
+	"abstract": true, "native": true, "synchronized": true, "transient": true,
+	"volatile": true, "strictfp": true, "default": true, "sealed": true,
+}
+
+// javaDecl is what precedes a declaration: its first token, Javadoc,
+// modifiers and annotations
+type javaDecl struct {
+	start       int
+	doc         string
+	modifiers   map[string]bool
+	annotations []string
+}
+
+// compilationUnit parses the package declaration, imports and top-level types
+func (f *javaFile) compilationUnit() {
+	for f.i < len(f.tokens) {
+		d := f.declPrefix()
+		switch f.peek() {
+		case "package":
+			f.packageDecl(d)
+		case "import":
+			f.i++
+			f.accept("static")
+			start := f.i
+			for f.i < len(f.tokens) && f.peek() != ";" {
+				f.i++
+			}
+			f.imports = append(f.imports, strings.Join(strings.Fields(f.text(start, f.i)), ""))
+			f.accept(";")
+		case "class", "interface", "enum", "record", "@":
+			f.typeDecl(d, "")
+		default:
+			f.i++
+		}
+	}
+}
+
+// declPrefix consumes the modifiers and annotations before a declaration
+func (f *javaFile) declPrefix() javaDecl {
+	d := javaDecl{start: f.i, doc: f.tok(f.i).doc, modifiers: make(map[string]bool)}
+	for f.i < len(f.tokens) {
+		switch {
+		case f.peek() == "non" && f.tok(f.i+1).text == "-" && f.tok(f.i+2).text == "sealed":
+			f.i += 3
+		case javaModifiers[f.peek()]:
+			d.modifiers[f.peek()] = true
+			f.i++
+		case f.peek() == "@" && f.tok(f.i+1).text != "interface":
+			d.annotations = append(d.annotations, f.annotation())
+		default:
+			return d
+		}
+	}
+	return d
+}
+
+// annotation consumes @Name or @Name(args) and returns it without the @
+func (f *javaFile) annotation() string {
+	f.i++ // @
+	start := f.i
+	for f.i < len(f.tokens) && isJavaName(f.peek()) {
+		f.i++
+		if f.peek() != "." || f.tok(f.i+1).text == "@" {
+			break
+		}
+		f.i++
+	}
+	if f.peek() == "(" {
+		f.skipBalanced()
+	}
+	return f.text(start, f.i)
+}
+
+// packageDecl records the package and its element (documented in package-info.java)
+func (f *javaFile) packageDecl(d javaDecl) {
+	f.i++ // package
+	start := f.i
+	for f.i < len(f.tokens) && f.peek() != ";" {
+		f.i++
+	}
+	f.pkg = strings.Join(strings.Fields(f.text(start, f.i)), "")
+	f.accept(";")
+
+	name := f.pkg
+	el := CodeElement{
+		Type:       TypePackage,
+		Name:       name,
+		ID:         name,
+		Decorators: d.annotations,
+		ImportPath: name,
+		Files:      []string{f.path},
+	}
+	f.add(&el, d, f.i)
+}
+
+// add fills in the location and source of an element spanning tokens d.start to end and records it
+func (f *javaFile) add(el *CodeElement, d javaDecl, end int) int {
+	first, last := f.tok(d.start), f.tok(end-1)
+	el.File = f.path
+	el.Line = f.line(first.pos)
+	el.EndLine = f.line(last.end - 1)
+	if el.Type != TypePackage {
+		el.ImportPath = f.pkg
+	}
+	el.Body = f.src[first.pos:last.end]
+	if d.doc != "" {
+		el.Docstring = docCommentText(d.doc)
+	}
+	el.Language = "java"
+	el.IndexedAt = time.Now()
+	f.elements = append(f.elements, *el)
+	return len(f.elements) - 1
+}
+
+// qualify prefixes a type or member name with the package
+func (f *javaFile) qualify(name string) string {
+	if f.pkg == "" {
+		return name
+	}
+	return f.pkg + "." + name
+}
+
+// typeDecl parses a class, interface, enum, record or annotation type
+// declaration. outer is the enclosing type of a nested one.
+func (f *javaFile) typeDecl(d javaDecl, outer string) {
+	kind := f.peek()
+	if kind == "@" { // @interface
+		kind = "@interface"
+		f.i++
+	}
+	f.i++
+	if f.i >= len(f.tokens) {
+		return
+	}
+	name := f.peek()
+	f.i++
+	if outer != "" {
+		name = outer + "." + name
+	}
+
+	el := CodeElement{
+		Name:       name,
+		ID:         f.qualify(name),
+		Decorators: d.annotations,
+		Methods:    []string{},
+		Exports:    d.modifiers["public"] || d.modifiers["protected"],
+	}
+	switch kind {
+	case "interface", "@interface":
+		el.Type = TypeInterface
+	case "enum":
+		el.Type = TypeType
+		el.EnumValues = []string{}
+	default:
+		el.Type = TypeClass
+	}
+
+	if f.peek() == "<" {
+		el.TypeParams = f.typeParams()
+	}
+	if kind == "record" && f.peek() == "(" {
+		for _, param := range f.params() {
+			el.Fields = append(el.Fields, Field{Name: param.Name, Type: param.Type})
+		}
+	}
+	for f.i < len(f.tokens) && f.peek() != "{" {
+		switch f.peek() {
+		case "extends":
+			f.i++
+			el.Extends = strings.Join(f.typeList(), ", ")
+		case "implements":
+			f.i++
+			el.Implements = f.typeList()
+		case "permits":
+			f.i++
+			f.typeList()
+		default:
+			f.i++
+		}
+	}
+
+	index := f.add(&el, d, f.i) // Body and end line are fixed up after the members
+	f.accept("{")
+	if kind == "enum" {
+		f.elements[index].EnumValues = f.enumConstants()
+	}
+	fields, methods := f.members(name, el.Type == TypeInterface, kind == "record")
+
+	t := &f.elements[index]
+	end := f.tok(f.i - 1)
+	t.Body = f.src[f.tok(d.start).pos:end.end]
+	t.EndLine = f.line(end.end - 1)
+	t.Fields = append(t.Fields, fields...)
+	t.Methods = append(t.Methods, methods...)
+}
+
+// enumConstants parses the constants that open an enum body
+func (f *javaFile) enumConstants() []string {
+	values := make([]string, 0)
+	for f.i < len(f.tokens) {
+		for f.peek() == "@" {
+			f.annotation()
+		}
+		switch f.peek() {
+		case ";":
+			f.i++
+			return values
+		case "}":
+			return values
+		case ",":
+			f.i++
+			continue
+		}
+		values = append(values, f.peek())
+		f.i++
+		if f.peek() == "(" {
+			f.skipBalanced()
+		}
+		if f.peek() == "{" { // Constant-specific class body
+			f.skipBalanced()
+		}
+	}
+	return values
+}
+
+// members parses a type body up to its closing brace, recording methods,
+// constructors and nested types, and returns the fields and method names
+func (f *javaFile) members(typeName string, inInterface, inRecord bool) ([]Field, []string) {
+	fields := make([]Field, 0)
+	methods := make([]string, 0)
+	simpleName := typeName[strings.LastIndex(typeName, ".")+1:]
+
+	for f.i < len(f.tokens) {
+		if f.accept("}") {
+			return fields, methods
+		}
+		if f.accept(";") {
+			continue
+		}
+		if f.peek() == "{" || f.peek() == "static" && f.tok(f.i+1).text == "{" {
+			f.accept("static")
+			f.skipBalanced() // Initializer block
+			continue
+		}
+
+		d := f.declPrefix()
+		switch f.peek() {
+		case "class", "interface", "enum", "record", "@":
+			if f.peek() != "record" || f.tok(f.i+2).text == "(" || f.tok(f.i+2).text == "<" {
+				f.typeDecl(d, typeName)
+				continue
+			}
+		}
+
+		el := CodeElement{
+			Type:       TypeFunction,
+			Decorators: d.annotations,
+			Exports:    d.modifiers["public"] || d.modifiers["protected"] || inInterface && !d.modifiers["private"],
+		}
+		if f.peek() == "<" {
+			el.TypeParams = f.typeParams()
+		}
+
+		// A constructor is named after its type; a compact record constructor has no parameter list
+		var name string
+		if f.peek() == simpleName && (f.tok(f.i+1).text == "(" || inRecord && f.tok(f.i+1).text == "{") {
+			name = simpleName
+			f.i++
+		} else {
+			typeStart := f.i
+			f.skipType()
+			returns := f.text(typeStart, f.i)
+			if f.i == typeStart || f.i >= len(f.tokens) {
+				f.i++
+				continue
+			}
+			name = f.peek()
+			f.i++
+			if f.peek() != "(" {
+				fields = append(fields, f.fieldDecl(d, name, returns)...)
+				continue
+			}
+			el.Returns = returns
+		}
+
+		if f.peek() == "(" {
+			el.Params = f.params()
+		}
+		for f.i < len(f.tokens) && f.peek() != "{" && f.peek() != ";" && f.peek() != "}" {
+			f.i++ // Array dimensions, throws clause, annotation default value
+		}
+		if f.peek() == "{" {
+			f.skipBalanced()
+		} else {
+			f.accept(";")
+		}
+
+		el.Name = typeName + "." + name
+		el.ID = f.qualify(el.Name) + "(" + javaErasures(el.Params) + ")"
+		f.add(&el, d, f.i)
+		methods = append(methods, name)
+	}
+	return fields, methods
+}
+
+// fieldDecl parses the rest of a field declaration (int a = 1, b[];) whose
+// first name has been read
+func (f *javaFile) fieldDecl(d javaDecl, name, typ string) []Field {
+	fields := make([]Field, 0, 1)
+	doc := ""
+	if d.doc != "" {
+		doc = docCommentText(d.doc)
+	}
+	for {
+		dims := ""
+		for f.peek() == "[" && f.tok(f.i+1).text == "]" {
+			dims += "[]"
+			f.i += 2
+		}
+		fields = append(fields, Field{Name: name, Type: typ + dims, Docstring: doc})
+		if f.accept("=") {
+			f.skipExpression()
+		}
+		if !f.accept(",") || f.i >= len(f.tokens) {
+			break
+		}
+		name = f.peek()
+		f.i++
+	}
+	f.accept(";")
+	return fields
+}
+
+// skipExpression skips an initializer up to the comma or semicolon ending it
+func (f *javaFile) skipExpression() {
+	depth := 0
+	for f.i < len(f.tokens) {
+		switch f.peek() {
+		case "(", "[", "{":
+			depth++
+		case ")", "]":
+			depth--
+		case "}":
+			if depth == 0 {
+				return
+			}
+			depth--
+		case ",", ";":
+			if depth == 0 {
+				return
+			}
+		}
+		f.i++
+	}
+}
+
+// typeParams parses <T extends Comparable<T>, U>
+func (f *javaFile) typeParams() []Parameter {
+	f.i++ // <
+	params := make([]Parameter, 0)
+	for f.i < len(f.tokens) && !f.accept(">") {
+		for f.peek() == "@" {
+			f.annotation()
+		}
+		param := Parameter{Name: f.peek()}
+		f.i++
+		if f.accept("extends") {
+			bounds := make([]string, 0)
+			for {
+				start := f.i
+				f.skipType()
+				bounds = append(bounds, f.text(start, f.i))
+				if !f.accept("&") {
+					break
+				}
+			}
+			param.Type = strings.Join(bounds, " & ")
+		}
+		params = append(params, param)
+		if !f.accept(",") && f.peek() != ">" {
+			f.i++
+		}
+	}
+	return params
+}
+
+// params parses a parenthesised parameter list
+func (f *javaFile) params() []Parameter {
+	f.i++ // (
+	params := make([]Parameter, 0)
+	for f.i < len(f.tokens) && !f.accept(")") {
+		for f.peek() == "@" || f.peek() == "final" {
+			if f.peek() == "@" {
+				f.annotation()
+			} else {
+				f.i++
+			}
+		}
+		start := f.i
+		f.skipType()
+		param := Parameter{Type: f.text(start, f.i)}
+		if f.accept("...") {
+			param.Type += "..."
+			param.Optional = true
+		}
+		if f.peek() != "," && f.peek() != ")" {
+			param.Name = f.peek()
+			f.i++
+		}
+		for f.peek() == "[" && f.tok(f.i+1).text == "]" {
+			param.Type += "[]"
+			f.i += 2
+		}
+		if param.Name != "this" { // Receiver parameter
+			params = append(params, param)
+		}
+		if !f.accept(",") && f.peek() != ")" {
+			f.i++
+		}
+	}
+	return params
+}
+
+// typeList parses a comma-separated list of types (extends A, B)
+func (f *javaFile) typeList() []string {
+	types := make([]string, 0)
+	for {
+		start := f.i
+		f.skipType()
+		if f.i == start {
+			return types
+		}
+		types = append(types, f.text(start, f.i))
+		if !f.accept(",") {
+			return types
+		}
+	}
+}
+
+// skipType skips a type: annotations, a qualified name with type arguments and array dimensions
+func (f *javaFile) skipType() {
+	for f.peek() == "@" {
+		f.annotation()
+	}
+	if f.peek() == "?" { // Wildcard
+		f.i++
+		if f.accept("extends") || f.accept("super") {
+			f.skipType()
+		}
+		return
+	}
+	for f.i < len(f.tokens) && isJavaName(f.peek()) {
+		f.i++
+		if f.peek() == "<" {
+			f.skipTypeArgs()
+		}
+		if !f.accept(".") {
+			break
+		}
+		for f.peek() == "@" {
+			f.annotation()
+		}
+	}
+	for f.peek() == "[" && f.tok(f.i+1).text == "]" {
+		f.i += 2
+	}
+}
+
+// skipTypeArgs skips <...> type arguments
+func (f *javaFile) skipTypeArgs() {
+	depth := 0
+	for f.i < len(f.tokens) {
+		switch f.peek() {
+		case "<":
+			depth++
+		case ">":
+			depth--
+		case ";", "{", "(", ")":
+			return // Not type arguments after all
+		}
+		f.i++
+		if depth == 0 {
+			return
+		}
+	}
+}
+
+// javaErasures renders parameter types without type arguments ("int,List,String[]"),
+// which tells overloads apart in IDs the way Javadoc links do
+func javaErasures(params []Parameter) string {
+	erased := make([]string, len(params))
+	for i, p := range params {
+		var sb strings.Builder
+		depth := 0
+		for _, r := range p.Type {
+			switch {
+			case r == '<':
+				depth++
+			case r == '>':
+				depth--
+			case depth == 0 && r != ' ':
+				sb.WriteRune(r)
+			}
+		}
+		erased[i] = sb.String()
+	}
+	return strings.Join(erased, ",")
+}
+
+// isJavaName reports whether s is an identifier (or keyword)
+func isJavaName(s string) bool {
+	r, _ := utf8.DecodeRuneInString(s)
+	return r == '_' || r == '$' || unicode.IsLetter(r)
+}
@@ -0,0 +1,341 @@
+package parser
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// jsTokenKind classifies the tokens of JavaScript and TypeScript source
+type jsTokenKind int
+
+const (
+	jsIdent  jsTokenKind = iota // Identifiers and keywords
+	jsNumber                    // Numeric literals
+	jsString                    // String and template literals
+	jsRegExp                    // Regular expression literals
+	jsJSX                       // A whole JSX element
+	jsPunct                     // Operators and punctuation
+)
+
+// jsToken is a token with its byte range in the source
+type jsToken struct {
+	srcToken
+	kind jsTokenKind
+	nl   bool   // A line break separates it from the previous token
+	doc  string // JSDoc comment (/** */) directly before it
+}
+
+// jsPuncts are the multi-character punctuators, longest first. ">" is always
+// a token of its own so that nested type arguments (Map<K, Set<V>>) close.
+var jsPuncts = []string{
+	"...", "===", "!==", "**=", "&&=", "||=", "??=", "<<=",
+	"=>", "==", "!=", "<=", "+=", "-=", "*=", "/=", "%=", "&=", "|=", "^=",
+	"&&", "||", "??", "?.", "++", "--", "<<", "**",
+}
+
+// jsRegExpAfter are the keywords after which a slash starts a regular expression
+var jsRegExpAfter = map[string]bool{
+	"return": true, "typeof": true, "instanceof": true, "in": true, "of": true,
+	"new": true, "delete": true, "void": true, "throw": true, "case": true,
+	"do": true, "else": true, "yield": true, "await": true,
+}
+
+// jsLexer splits JavaScript/TypeScript source into tokens. Comments are
+// dropped (JSDoc is kept on the following token); templates and JSX
+// elements become single tokens, as declarations never look inside them.
+type jsLexer struct {
+	src  string
+	pos  int
+	jsx  bool
+	last *jsToken // Last token produced, to tell regular expressions from division
+	nl   bool
+	doc  string
+}
+
+// tokenizeJS returns the tokens of src; jsx enables JSX elements
+func tokenizeJS(src string, jsx bool) []jsToken {
+	l := &jsLexer{src: src, jsx: jsx}
+	tokens := make([]jsToken, 0, len(src)/4)
+	for {
+		tok, ok := l.next()
+		if !ok {
+			return tokens
+		}
+		tokens = append(tokens, tok)
+	}
+}
+
+// next returns the next token, or false at the end of the source
+func (l *jsLexer) next() (jsToken, bool) {
+	l.skipSpace()
+	if l.pos >= len(l.src) {
+		return jsToken{}, false
+	}
+
+	start := l.pos
+	c := l.src[l.pos]
+	kind := jsPunct
+	switch {
+	case c == '"' || c == '\'':
+		l.skipQuoted(c)
+		kind = jsString
+	case c == '`':
+		l.skipTemplate()
+		kind = jsString
+	case c >= '0' && c <= '9' || c == '.' && l.pos+1 < len(l.src) && isDigit(l.src[l.pos+1]):
+		for l.pos < len(l.src) && (isIdentByte(l.src[l.pos]) || l.src[l.pos] == '.') {
+			l.pos++
+		}
+		kind = jsNumber
+	case c == '#' || c == '$' || c == '_' || c == '\\' || c >= 0x80 || unicode.IsLetter(rune(c)):
+		l.pos++ // "#" starts private class members
+		for l.pos < len(l.src) {
+			r, size := utf8.DecodeRuneInString(l.src[l.pos:])
+			if r != '$' && r != '_' && r != '\\' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+				break
+			}
+			l.pos += size
+		}
+		kind = jsIdent
+	case c == '/' && l.regExpAllowed():
+		l.skipRegExp()
+		kind = jsRegExp
+	case c == '<' && l.jsx && l.regExpAllowed() && l.pos+1 < len(l.src) &&
+		(l.src[l.pos+1] == '>' || unicode.IsLetter(rune(l.src[l.pos+1]))):
+		l.skipJSX()
+		kind = jsJSX
+	default:
+		l.pos++
+		for _, p := range jsPuncts {
+			if strings.HasPrefix(l.src[start:], p) {
+				l.pos = start + len(p)
+				break
+			}
+		}
+	}
+
+	tok := jsToken{srcToken: srcToken{text: l.src[start:l.pos], pos: start, end: l.pos}, kind: kind, nl: l.nl, doc: l.doc}
+	l.nl, l.doc = false, ""
+	l.last = &tok
+	return tok, true
+}
+
+// skipSpace skips whitespace and comments, noting line breaks and JSDoc
+func (l *jsLexer) skipSpace() {
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch {
+		case c == '\n':
+			l.nl = true
+			l.pos++
+		case c == ' ' || c == '\t' || c == '\r' || c == '\f' || c == '\v':
+			l.pos++
+		case strings.HasPrefix(l.src[l.pos:], "//"):
+			end := strings.IndexByte(l.src[l.pos:], '\n')
+			if end < 0 {
+				end = len(l.src) - l.pos
+			}
+			l.pos += end
+		case strings.HasPrefix(l.src[l.pos:], "/*"):
+			end := strings.Index(l.src[l.pos+2:], "*/")
+			if end < 0 {
+				end = len(l.src) - l.pos - 4
+			}
+			comment := l.src[l.pos : l.pos+end+4]
+			if strings.Contains(comment, "\n") {
+				l.nl = true
+			}
+			if strings.HasPrefix(comment, "/**") && comment != "/**/" {
+				l.doc = comment
+			}
+			l.pos += end + 4
+		case strings.HasPrefix(l.src[l.pos:], "#!") && l.pos == 0:
+			end := strings.IndexByte(l.src, '\n')
+			if end < 0 {
+				end = len(l.src)
+			}
+			l.pos = end
+		default:
+			return
+		}
+	}
+}
+
+// regExpAllowed reports whether an expression may start here, where a
+// slash begins a regular expression (and "<" a JSX element) rather than
+// being an operator
+func (l *jsLexer) regExpAllowed() bool {
+	if l.last == nil {
+		return true
+	}
+	switch l.last.kind {
+	case jsNumber, jsString, jsRegExp, jsJSX:
+		return false
+	case jsIdent:
+		return jsRegExpAfter[l.last.text]
+	}
+	switch l.last.text {
+	case ")", "]", "}", "++", "--":
+		return false
+	}
+	return true
+}
+
+// skipQuoted skips a string literal delimited by quote
+func (l *jsLexer) skipQuoted(quote byte) {
+	l.pos++
+	for l.pos < len(l.src) {
+		switch l.src[l.pos] {
+		case '\\':
+			l.pos++
+		case quote:
+			l.pos++
+			return
+		case '\n':
+			return // Unterminated
+		}
+		l.pos++
+	}
+}
+
+// skipTemplate skips a template literal, including its ${} substitutions
+func (l *jsLexer) skipTemplate() {
+	l.pos++
+	for l.pos < len(l.src) {
+		switch {
+		case l.src[l.pos] == '\\':
+			l.pos += 2
+		case l.src[l.pos] == '`':
+			l.pos++
+			return
+		case strings.HasPrefix(l.src[l.pos:], "${"):
+			l.pos += 2
+			l.skipBraced()
+		default:
+			l.pos++
+		}
+	}
+}
+
+// skipBraced skips tokens up to and including the brace closing an
+// already opened one
+func (l *jsLexer) skipBraced() {
+	saved, savedNL, savedDoc := l.last, l.nl, l.doc
+	l.last = nil
+	depth := 1
+	for depth > 0 {
+		tok, ok := l.next()
+		if !ok {
+			break
+		}
+		switch tok.text {
+		case "{":
+			depth++
+		case "}":
+			depth--
+		}
+	}
+	l.last, l.nl, l.doc = saved, savedNL, savedDoc
+}
+
+// skipRegExp skips a regular expression literal and its flags
+func (l *jsLexer) skipRegExp() {
+	l.pos++
+	inClass := false
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		l.pos++
+		switch {
+		case c == '\\':
+			l.pos++
+		case c == '[':
+			inClass = true
+		case c == ']':
+			inClass = false
+		case c == '/' && !inClass:
+			for l.pos < len(l.src) && isIdentByte(l.src[l.pos]) {
+				l.pos++
+			}
+			return
+		case c == '\n':
+			return
+		}
+	}
+}
+
+// skipJSX skips a JSX element (or fragment) with all of its children
+func (l *jsLexer) skipJSX() {
+	depth := 0
+	for l.pos < len(l.src) {
+		switch c := l.src[l.pos]; {
+		case c == '<' && l.pos+1 < len(l.src) && l.src[l.pos+1] == '/':
+			end := strings.IndexByte(l.src[l.pos:], '>')
+			if end < 0 {
+				l.pos = len(l.src)
+				return
+			}
+			l.pos += end + 1
+			if depth--; depth <= 0 {
+				return
+			}
+		case c == '<':
+			if l.skipJSXTag() {
+				depth++
+			} else if depth == 0 {
+				return // Self-closing
+			}
+		case c == '{':
+			l.pos++
+			l.skipBraced()
+		default:
+			l.pos++
+		}
+	}
+}
+
+// skipJSXTag skips an opening tag and reports whether it has children (is not self-closing)
+func (l *jsLexer) skipJSXTag() bool {
+	l.pos++
+	for l.pos < len(l.src) {
+		switch c := l.src[l.pos]; {
+		case c == '"' || c == '\'':
+			l.skipQuoted(c)
+		case c == '{':
+			l.pos++
+			l.skipBraced()
+		case c == '/' && l.pos+1 < len(l.src) && l.src[l.pos+1] == '>':
+			l.pos += 2
+			return false
+		case c == '>':
+			l.pos++
+			return true
+		default:
+			l.pos++
+		}
+	}
+	return false
+}
+
+// isIdentByte reports whether c can continue an ASCII identifier or number
+func isIdentByte(c byte) bool {
+	return c == '_' || c == '$' || isDigit(c) || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// isDigit reports whether c is a decimal digit
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// docCommentText strips the comment markers and leading asterisks from a
+// /** */ doc comment (JSDoc, Javadoc)
+func docCommentText(comment string) string {
+	comment = strings.TrimSuffix(strings.TrimPrefix(comment, "/**"), "*/")
+	lines := strings.Split(comment, "\n")
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		line = strings.TrimPrefix(line, "*")
+		lines[i] = strings.TrimPrefix(line, " ")
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
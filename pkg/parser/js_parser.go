@@ -0,0 +1,973 @@
+package parser
+
+import (
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// JSParser parses JavaScript and TypeScript (including JSX/TSX) source. It
+// reads declarations only: function bodies and initializers are skipped as
+// balanced token runs, which keeps it tolerant of syntax it does not know.
+type JSParser struct{}
+
+// NewJSParser creates a new JavaScript/TypeScript parser
+func NewJSParser() *JSParser {
+	return &JSParser{}
+}
+
+// jsExtensions maps the supported file extensions to their language
+var jsExtensions = map[string]string{
+	".js": "javascript", ".jsx": "javascript", ".mjs": "javascript", ".cjs": "javascript",
+	".ts": "typescript", ".tsx": "typescript", ".mts": "typescript", ".cts": "typescript",
+}
+
+// SupportsFile checks if the parser supports this file
+func (p *JSParser) SupportsFile(filePath string) bool {
+	_, ok := jsExtensions[filepath.Ext(filePath)]
+	return ok
+}
+
+// Parse parses JavaScript or TypeScript source and extracts its declarations
+func (p *JSParser) Parse(filePath string, content []byte) (*ParseResult, error) {
+	ext := filepath.Ext(filePath)
+	src := string(content)
+
+	// Plain .ts files use <T>x for type assertions, so only they lack JSX
+	jsx := ext != ".ts" && ext != ".mts" && ext != ".cts"
+	module := strings.TrimSuffix(filepath.ToSlash(filePath), ext)
+
+	f := &jsFile{
+		tokenCursor: newTokenCursor(tokenizeJS(src, jsx), src, jsToken{kind: jsPunct, srcToken: srcToken{pos: len(src), end: len(src)}}),
+		path:        filePath,
+		module:      module,
+		language:    firstOf(jsExtensions[ext], "javascript"), // Node scripts lack an extension
+		exported:    make(map[string]bool),
+	}
+	f.statements("", true)
+
+	// export { a, b as c } and module.exports = { a } export earlier declarations
+	for i := range f.elements {
+		el := &f.elements[i]
+		if !strings.Contains(el.Name, ".") && f.exported[el.Name] {
+			el.Exports = true
+		}
+		el.Imports = f.imports
+		el.Hash = HashCode(el.ID + "\n" + el.Body)
+	}
+
+	return &ParseResult{
+		Elements: f.elements,
+		Errors:   make([]ParseError, 0),
+	}, nil
+}
+
+// jsFile is the parsing state of one JavaScript/TypeScript file
+type jsFile struct {
+	tokenCursor[jsToken]
+	path     string
+	module   string // File path without extension, which prefixes IDs
+	language string
+	imports  []string
+	exported map[string]bool
+	elements []CodeElement
+	ids      map[string]int // Element index by ID, to merge overloads
+}
+
+// jsModifiers are the keywords that can precede a declaration
+var jsModifiers = map[string]bool{
+	"export": true, "default": true, "declare": true, "abstract": true, "async": true,
+}
+
+// statements parses declarations up to the end of the file (top) or the
+// closing brace of a namespace. prefix qualifies the names declared.
+func (f *jsFile) statements(prefix string, top bool) {
+	for f.i < len(f.tokens) {
+		if !top && f.peek() == "}" {
+			f.i++
+			return
+		}
+		f.statement(prefix, top)
+	}
+}
+
+// statement parses one statement, recording any declaration it makes
+func (f *jsFile) statement(prefix string, top bool) {
+	start := f.i
+	doc := f.tok(start).doc
+	f.skipDecorators()
+
+	exported, isDefault, async := false, false, false
+	for jsModifiers[f.peek()] && f.i+1 < len(f.tokens) && !f.tok(f.i+1).nl || f.peek() == "export" {
+		switch f.peek() {
+		case "export":
+			exported = true
+			next := f.tok(f.i + 1).text
+			if next == "{" || next == "*" || next == "=" {
+				f.i++
+				f.exportList()
+				return
+			}
+		case "default":
+			isDefault = true
+		case "async":
+			async = true
+		}
+		f.i++
+		f.skipDecorators()
+	}
+	if !top {
+		exported = exported || prefix == ""
+	}
+
+	d := jsDecl{start: start, doc: doc, prefix: prefix, exported: exported, async: async}
+	switch tok := f.tok(f.i); {
+	case tok.text == "function":
+		f.function(d, isDefault)
+	case tok.text == "class":
+		f.class(d, isDefault)
+	case tok.text == "interface" && f.tok(f.i+1).kind == jsIdent:
+		f.iface(d)
+	case tok.text == "type" && f.tok(f.i+1).kind == jsIdent && !f.tok(f.i+1).nl:
+		f.typeAlias(d)
+	case tok.text == "enum" || tok.text == "const" && f.tok(f.i+1).text == "enum":
+		f.accept("const")
+		f.enum(d)
+	case tok.text == "const" || tok.text == "let" || tok.text == "var" || tok.text == "using":
+		f.variables(d)
+	case (tok.text == "namespace" || tok.text == "module") && !f.tok(f.i+1).nl &&
+		(f.tok(f.i+1).kind == jsIdent || f.tok(f.i+1).kind == jsString):
+		f.namespace(d)
+	case tok.text == "global" && f.tok(f.i+1).text == "{":
+		f.i += 2
+		f.statements(prefix, false)
+	case tok.text == "import" && f.tok(f.i+1).text != "(" && f.tok(f.i+1).text != ".":
+		f.importDecl()
+	case isDefault:
+		// export default <expression>
+		if f.isFunctionValue(f.i) {
+			f.arrowFunction(d, "default", f.i)
+			return
+		}
+		if name := f.tok(f.i); name.kind == jsIdent {
+			f.exported[name.text] = true
+		}
+		f.skipExpression(nil)
+		f.accept(";")
+	case top && (tok.text == "module" || tok.text == "exports"):
+		f.commonJS(d)
+	case tok.text == "{":
+		f.skipBalanced()
+	default:
+		f.skipExpression(nil)
+		if f.i == start {
+			f.i++ // Never stall on a stray token
+		}
+		f.accept(";")
+	}
+}
+
+// jsDecl carries what the statement parser found before the declaration keyword
+type jsDecl struct {
+	start    int
+	doc      string
+	prefix   string
+	exported bool
+	async    bool
+}
+
+// add records an element spanning tokens start to end (exclusive) and returns its index
+func (f *jsFile) add(el CodeElement, d jsDecl, end int) int {
+	first, last := f.tok(d.start), f.tok(end-1)
+	el.Name = d.prefix + el.Name
+	el.ID = f.module + "." + el.Name
+	el.File = f.path
+	el.Line = f.line(first.pos)
+	el.EndLine = f.line(last.end - 1)
+	el.ImportPath = f.module
+	el.Body = f.src[first.pos:last.end]
+	if el.Docstring == "" && d.doc != "" {
+		el.Docstring = docCommentText(d.doc)
+	}
+	el.Language = f.language
+	el.IndexedAt = time.Now()
+
+	// TypeScript overloads share a name; the implementation (with a body) wins
+	if f.ids == nil {
+		f.ids = make(map[string]int)
+	}
+	if i, ok := f.ids[el.ID]; ok && el.Type.IsFunction() && f.elements[i].Type.IsFunction() {
+		if strings.HasSuffix(el.Body, "}") {
+			el.Docstring = firstOf(el.Docstring, f.elements[i].Docstring)
+			f.elements[i] = el
+		}
+		return i
+	}
+	f.ids[el.ID] = len(f.elements)
+	f.elements = append(f.elements, el)
+	return len(f.elements) - 1
+}
+
+// firstOf returns the first non-empty string
+func firstOf(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// function parses a function declaration: function* name<T>(params): R { ... }
+func (f *jsFile) function(d jsDecl, isDefault bool) {
+	f.i++ // function
+	generator := f.accept("*")
+	name := "default"
+	if f.tok(f.i).kind == jsIdent {
+		name = f.peek()
+		f.i++
+	} else if !isDefault {
+		f.skipExpression(nil)
+		return
+	}
+
+	el := CodeElement{Type: TypeFunction, Name: name, Async: d.async, Generator: generator, Exports: d.exported}
+	f.signature(&el)
+	if f.peek() == "{" {
+		f.skipBalanced()
+	} else {
+		f.accept(";") // Overload or ambient declaration
+	}
+	f.add(el, d, f.i)
+}
+
+// signature parses type parameters, parameters and return type into el
+func (f *jsFile) signature(el *CodeElement) {
+	if f.peek() == "<" {
+		el.TypeParams = f.typeParams()
+	}
+	if f.peek() == "(" {
+		el.Params = f.params()
+	}
+	if f.accept(":") {
+		el.Returns = f.typeText(map[string]bool{"{": true, ";": true, ",": true, "=>": true})
+	}
+}
+
+// typeParams parses <T extends U = V, ...>
+func (f *jsFile) typeParams() []Parameter {
+	f.i++ // <
+	params := make([]Parameter, 0)
+	for f.i < len(f.tokens) && !f.accept(">") {
+		f.accept("const")
+		f.accept("in")
+		f.accept("out")
+		param := Parameter{Name: f.peek()}
+		f.i++
+		if f.accept("extends") {
+			param.Type = f.typeText(map[string]bool{",": true, ">": true, "=": true})
+		}
+		if f.accept("=") {
+			param.Default = f.typeText(map[string]bool{",": true, ">": true})
+		}
+		params = append(params, param)
+		f.accept(",")
+	}
+	return params
+}
+
+// params parses a parenthesised parameter list
+func (f *jsFile) params() []Parameter {
+	f.i++ // (
+	params := make([]Parameter, 0)
+	for f.i < len(f.tokens) && !f.accept(")") {
+		f.skipDecorators()
+		for isParamModifier(f.peek()) && f.tok(f.i+1).kind == jsIdent {
+			f.i++
+		}
+
+		param := Parameter{}
+		rest := f.accept("...")
+		switch f.peek() {
+		case "{", "[":
+			start := f.tok(f.i).pos
+			f.skipBalanced()
+			param.Name = f.src[start:f.tok(f.i-1).end]
+		default:
+			param.Name = f.peek()
+			f.i++
+		}
+		if rest {
+			param.Name = "..." + param.Name
+		}
+		if f.accept("?") {
+			param.Optional = true
+		}
+		if f.accept(":") {
+			param.Type = f.typeText(map[string]bool{",": true, ")": true, "=": true})
+		}
+		if f.accept("=") {
+			start := f.i
+			f.skipExpression(map[string]bool{",": true})
+			param.Default = f.text(start, f.i)
+			param.Optional = true
+		}
+		if param.Name != "this" { // TypeScript's this parameter only types the receiver
+			params = append(params, param)
+		}
+		if !f.accept(",") && f.peek() != ")" {
+			f.i++ // Unexpected token; keep going
+		}
+	}
+	return params
+}
+
+// isParamModifier reports whether word is a constructor parameter property modifier
+func isParamModifier(word string) bool {
+	switch word {
+	case "public", "private", "protected", "readonly", "override":
+		return true
+	}
+	return false
+}
+
+// class parses a class declaration with its members
+func (f *jsFile) class(d jsDecl, isDefault bool) {
+	f.i++ // class
+	name := "default"
+	if tok := f.tok(f.i); tok.kind == jsIdent && tok.text != "extends" && tok.text != "implements" {
+		name = tok.text
+		f.i++
+	} else if !isDefault {
+		f.skipExpression(nil)
+		return
+	}
+
+	el := CodeElement{Type: TypeClass, Name: name, Exports: d.exported, Methods: []string{}}
+	if f.peek() == "<" {
+		el.TypeParams = f.typeParams()
+	}
+	if f.accept("extends") {
+		start := f.i
+		f.skipExpression(map[string]bool{"{": true, "implements": true})
+		el.Extends = f.text(start, f.i)
+	}
+	if f.accept("implements") {
+		for {
+			el.Implements = append(el.Implements, f.typeText(map[string]bool{",": true, "{": true}))
+			if !f.accept(",") {
+				break
+			}
+		}
+	}
+	if f.peek() != "{" {
+		f.add(el, d, f.i)
+		return
+	}
+
+	classIndex := f.add(el, d, f.i) // Body and end line are fixed up once the members are read
+	f.i++                           // {
+	methods := make([]CodeElement, 0)
+	for f.i < len(f.tokens) && !f.accept("}") {
+		if member, isMethod := f.classMember(d.prefix+name, d.exported); isMethod {
+			methods = append(methods, member)
+		} else if member.Name != "" {
+			el.Fields = append(el.Fields, Field{Name: member.Name, Type: member.ValueType, Docstring: member.Docstring})
+		}
+	}
+
+	cls := &f.elements[classIndex]
+	end := f.tok(f.i - 1)
+	cls.Body = f.src[f.tok(d.start).pos:end.end]
+	cls.EndLine = f.line(end.end - 1)
+	cls.Fields = el.Fields
+	for _, m := range methods {
+		cls.Methods = append(cls.Methods, strings.TrimPrefix(m.Name, d.prefix+name+"."))
+	}
+}
+
+// classMember parses one class member. Methods (and fields initialised with
+// functions) are recorded as elements and reported as such; fields are
+// returned for the class to list.
+func (f *jsFile) classMember(class string, classExported bool) (CodeElement, bool) {
+	if f.accept(";") {
+		return CodeElement{}, false
+	}
+	d := jsDecl{start: f.i, doc: f.tok(f.i).doc, prefix: class + "."}
+	f.skipDecorators()
+
+	private := false
+modifiers:
+	for {
+		switch word, next := f.peek(), f.tok(f.i+1); {
+		case word == "static" && next.text == "{":
+			f.i++
+			f.skipBalanced() // Static initialisation block
+			return CodeElement{}, false
+		case word == "private" || word == "protected":
+			private = true
+		case word == "async":
+			d.async = true
+		case (word == "static" || word == "public" || word == "readonly" || word == "abstract" ||
+			word == "override" || word == "declare" || word == "accessor" || word == "get" || word == "set") &&
+			(next.kind == jsIdent || next.kind == jsString || next.text == "[" || next.text == "*") && !next.nl:
+		default:
+			break modifiers
+		}
+		f.i++
+	}
+
+	generator := f.accept("*")
+	name := f.peek()
+	switch f.peek() {
+	case "[": // Computed name or index signature
+		start := f.i
+		f.skipBalanced()
+		name = f.text(start, f.i)
+		if strings.Contains(name, ":") {
+			f.skipExpression(map[string]bool{"}": true})
+			f.accept(";")
+			return CodeElement{}, false
+		}
+	default:
+		f.i++
+	}
+	if strings.HasPrefix(name, "#") {
+		private = true
+	}
+	f.accept("?")
+	f.accept("!")
+
+	el := CodeElement{Name: name, Exports: classExported && !private, Async: d.async, Generator: generator}
+	if f.peek() == "(" || f.peek() == "<" {
+		el.Type = TypeFunction
+		f.signature(&el)
+		if f.peek() == "{" {
+			f.skipBalanced()
+		} else {
+			f.accept(";")
+		}
+		f.add(el, d, f.i)
+		return el, true
+	}
+
+	// A field: name: Type = value
+	if f.accept(":") {
+		el.ValueType = f.typeText(map[string]bool{";": true, "=": true, "}": true})
+	}
+	if f.accept("=") {
+		if f.isFunctionValue(f.i) {
+			f.functionValue(&el)
+			f.accept(";")
+			el.Type = TypeFunction
+			f.add(el, d, f.i)
+			return el, true
+		}
+		f.skipExpression(map[string]bool{"}": true})
+	}
+	f.accept(";")
+	if d.doc != "" {
+		el.Docstring = docCommentText(d.doc)
+	}
+	return el, false
+}
+
+// iface parses an interface declaration
+func (f *jsFile) iface(d jsDecl) {
+	f.i++ // interface
+	el := CodeElement{Type: TypeInterface, Name: f.peek(), Exports: d.exported, Methods: []string{}}
+	f.i++
+	if f.peek() == "<" {
+		el.TypeParams = f.typeParams()
+	}
+	if f.accept("extends") {
+		extends := make([]string, 0)
+		for {
+			extends = append(extends, f.typeText(map[string]bool{",": true, "{": true}))
+			if !f.accept(",") {
+				break
+			}
+		}
+		el.Extends = strings.Join(extends, ", ")
+	}
+
+	if f.accept("{") {
+		el.Fields, el.Methods = f.typeMembers()
+	}
+	f.add(el, d, f.i)
+}
+
+// typeMembers parses the members of an interface or object type up to its
+// closing brace: properties become fields, method signatures methods
+func (f *jsFile) typeMembers() ([]Field, []string) {
+	fields := make([]Field, 0)
+	methods := make([]string, 0)
+	for f.i < len(f.tokens) && !f.accept("}") {
+		if f.accept(";") || f.accept(",") {
+			continue
+		}
+		doc := f.tok(f.i).doc
+		f.accept("readonly")
+
+		name := f.peek()
+		switch name {
+		case "[", "(", "<", "new": // Index, call and construct signatures
+			f.skipExpression(map[string]bool{";": true, ",": true, "}": true})
+			continue
+		}
+		f.i++
+		optional := f.accept("?")
+
+		if f.peek() == "(" || f.peek() == "<" {
+			method := CodeElement{}
+			f.signature(&method)
+			methods = append(methods, name)
+			continue
+		}
+		field := Field{Name: name}
+		if optional {
+			field.Name += "?"
+		}
+		if f.accept(":") {
+			field.Type = f.typeText(map[string]bool{";": true, ",": true, "}": true})
+		}
+		if doc != "" {
+			field.Docstring = docCommentText(doc)
+		}
+		fields = append(fields, field)
+	}
+	return fields, methods
+}
+
+// typeAlias parses type Name<T> = ...
+func (f *jsFile) typeAlias(d jsDecl) {
+	f.i++ // type
+	el := CodeElement{Type: TypeType, Name: f.peek(), Exports: d.exported}
+	f.i++
+	if f.peek() == "<" {
+		el.TypeParams = f.typeParams()
+	}
+	if f.accept("=") {
+		if f.peek() == "{" {
+			// An object type reads like an interface
+			f.i++
+			el.Fields, el.Methods = f.typeMembers()
+		}
+		f.typeText(map[string]bool{";": true})
+	}
+	f.accept(";")
+	f.add(el, d, f.i)
+}
+
+// enum parses enum Name { A, B = 2 }
+func (f *jsFile) enum(d jsDecl) {
+	f.i++ // enum
+	el := CodeElement{Type: TypeType, Name: f.peek(), Exports: d.exported}
+	f.i++
+	if f.accept("{") {
+		el.EnumValues = make([]string, 0)
+		for f.i < len(f.tokens) && !f.accept("}") {
+			if f.accept(",") {
+				continue
+			}
+			name := f.peek()
+			if f.tok(f.i).kind == jsString {
+				name = strings.Trim(name, `"'`)
+			}
+			el.EnumValues = append(el.EnumValues, name)
+			f.i++
+			if f.accept("=") {
+				f.skipExpression(map[string]bool{",": true, "}": true})
+			}
+		}
+	}
+	f.add(el, d, f.i)
+}
+
+// namespace parses namespace A.B { ... } and declare module "x" { ... }
+func (f *jsFile) namespace(d jsDecl) {
+	f.i++ // namespace/module
+	name := ""
+	for f.tok(f.i).kind == jsIdent || f.peek() == "." {
+		name += f.peek()
+		f.i++
+	}
+	if name == "" { // declare module "x": ambient declarations of another module
+		f.i++
+		if !f.accept("{") {
+			f.accept(";")
+			return
+		}
+		f.statements(d.prefix, false)
+		return
+	}
+	if f.accept("{") {
+		f.statements(d.prefix+name+".", false)
+	}
+}
+
+// variables parses const/let/var declarations. Function and arrow function
+// values are indexed as functions; other values as constants or variables.
+func (f *jsFile) variables(d jsDecl) {
+	kind := TypeVariable
+	if f.peek() == "const" {
+		kind = TypeConstant
+	}
+	f.i++
+
+	for f.i < len(f.tokens) {
+		if f.tok(f.i).kind != jsIdent { // Destructuring
+			f.skipExpression(map[string]bool{";": true})
+			break
+		}
+		el := CodeElement{Type: kind, Name: f.peek(), Exports: d.exported}
+		f.i++
+		f.accept("!")
+		if f.accept(":") {
+			el.ValueType = f.typeText(map[string]bool{"=": true, ",": true, ";": true})
+		}
+
+		valueStart := f.i
+		if f.accept("=") {
+			valueStart = f.i
+			switch {
+			case f.isFunctionValue(f.i):
+				el.Type = TypeFunction
+				f.functionValue(&el)
+			case f.isRequire(f.i):
+				f.imports = append(f.imports, strings.Trim(f.tok(f.i+2).text, "\"'`"))
+				f.skipExpression(map[string]bool{",": true})
+				el.Name = "" // An import, not a value
+			default:
+				f.skipExpression(map[string]bool{",": true})
+				if value := f.text(valueStart, f.i); !strings.Contains(value, "\n") {
+					el.Value = value
+				}
+			}
+		}
+
+		if el.Name != "" {
+			end := f.i
+			if f.peek() == ";" {
+				end++
+			}
+			f.add(el, d, end)
+		}
+		d.start, d.doc = f.i, ""
+		if !f.accept(",") {
+			break
+		}
+		d.start = f.i
+	}
+	f.accept(";")
+}
+
+// isRequire reports whether tokens at i are require("module")
+func (f *jsFile) isRequire(i int) bool {
+	return f.tok(i).text == "require" && f.tok(i+1).text == "(" && f.tok(i+2).kind == jsString
+}
+
+// isFunctionValue reports whether the expression at i is a function or arrow function
+func (f *jsFile) isFunctionValue(i int) bool {
+	if f.tok(i).text == "async" && !f.tok(i+1).nl {
+		i++
+	}
+	switch tok := f.tok(i); {
+	case tok.text == "function":
+		return true
+	case tok.kind == jsIdent:
+		return f.tok(i+1).text == "=>"
+	case tok.text == "(" || tok.text == "<":
+		return f.isArrow(i)
+	}
+	return false
+}
+
+// isArrow reports whether the tokens at i (type parameters or a parameter
+// list) are followed by => and so start an arrow function
+func (f *jsFile) isArrow(i int) bool {
+	if f.tok(i).text == "<" {
+		i = f.matching(i, "<", ">") + 1
+	}
+	if f.tok(i).kind == jsIdent && f.tok(i+1).text == "=>" {
+		return true
+	}
+	if f.tok(i).text != "(" {
+		return false
+	}
+	i = f.matching(i, "(", ")") + 1
+	if f.tok(i).text == ":" {
+		saved := f.i
+		f.i = i + 1
+		f.typeText(map[string]bool{"=>": true, ";": true})
+		i = f.i
+		f.i = saved
+	}
+	return f.tok(i).text == "=>"
+}
+
+// matching returns the index of the token closing the one at i
+func (f *jsFile) matching(i int, open, close string) int {
+	depth := 0
+	for ; i < len(f.tokens); i++ {
+		switch f.tokens[i].text {
+		case open:
+			depth++
+		case close:
+			if depth--; depth == 0 {
+				return i
+			}
+		}
+	}
+	return len(f.tokens)
+}
+
+// functionValue parses a function expression or arrow function into el
+func (f *jsFile) functionValue(el *CodeElement) {
+	if f.peek() == "async" {
+		el.Async = true
+		f.i++
+	}
+	if f.accept("function") {
+		el.Generator = f.accept("*")
+		if f.tok(f.i).kind == jsIdent && f.peek() != "(" && f.peek() != "<" {
+			f.i++ // Name of a named function expression
+		}
+		f.signature(el)
+		f.skipBalanced()
+		return
+	}
+
+	if f.tok(f.i).kind == jsIdent {
+		el.Params = []Parameter{{Name: f.peek()}}
+		f.i++
+	} else {
+		f.signature(el)
+	}
+	f.accept("=>")
+	if f.peek() == "{" {
+		f.skipBalanced()
+	} else {
+		f.skipExpression(map[string]bool{",": true})
+	}
+}
+
+// arrowFunction records the arrow function at token i as a function called name
+func (f *jsFile) arrowFunction(d jsDecl, name string, i int) {
+	el := CodeElement{Type: TypeFunction, Name: name, Exports: d.exported}
+	f.i = i
+	f.functionValue(&el)
+	f.accept(";")
+	f.add(el, d, f.i)
+}
+
+// commonJS handles module.exports = ... and exports.name = ...
+func (f *jsFile) commonJS(d jsDecl) {
+	start := f.i
+	name := ""
+	switch {
+	case f.peek() == "module" && f.tok(f.i+1).text == "." && f.tok(f.i+2).text == "exports":
+		f.i += 3
+		if f.tok(f.i).text == "." {
+			name = f.tok(f.i + 1).text
+			f.i += 2
+		}
+	case f.peek() == "exports" && f.tok(f.i+1).text == ".":
+		name = f.tok(f.i + 2).text
+		f.i += 3
+	}
+	if f.i == start || !f.accept("=") {
+		f.i = start
+		f.skipExpression(nil)
+		f.accept(";")
+		return
+	}
+
+	d.exported = true
+	switch {
+	case name != "" && f.isFunctionValue(f.i):
+		f.arrowFunction(d, name, f.i)
+	case name == "" && f.peek() == "{":
+		// module.exports = { a, b: c, d() {} }
+		end := f.matching(f.i, "{", "}")
+		for i := f.i + 1; i < end; i++ {
+			if tok := f.tokens[i]; tok.kind == jsIdent && (f.tokens[i-1].text == "{" || f.tokens[i-1].text == ",") {
+				f.exported[tok.text] = true
+				if next := f.tok(i + 1).text; next == ":" || next == "(" {
+					i = f.skipProperty(i+1, end)
+				}
+			}
+		}
+		f.i = end + 1
+		f.accept(";")
+	case name == "" && f.isFunctionValue(f.i):
+		f.arrowFunction(d, "default", f.i)
+	default:
+		if tok := f.tok(f.i); tok.kind == jsIdent {
+			f.exported[tok.text] = true
+		}
+		f.skipExpression(nil)
+		f.accept(";")
+	}
+}
+
+// skipProperty returns the index of the comma ending an object property value (or end)
+func (f *jsFile) skipProperty(i, end int) int {
+	depth := 0
+	for ; i < end; i++ {
+		switch f.tokens[i].text {
+		case "(", "[", "{":
+			depth++
+		case ")", "]", "}":
+			depth--
+		case ",":
+			if depth == 0 {
+				return i - 1
+			}
+		}
+	}
+	return end
+}
+
+// importDecl records the module of an import declaration
+func (f *jsFile) importDecl() {
+	f.i++ // import
+	for f.i < len(f.tokens) {
+		tok := f.tok(f.i)
+		if tok.kind == jsString {
+			f.imports = append(f.imports, strings.Trim(tok.text, "\"'`"))
+			f.i++
+			break
+		}
+		if tok.text == ";" || tok.nl && f.i > 0 && f.tok(f.i-1).text != "," && f.tok(f.i-1).text != "{" &&
+			tok.text != "from" && tok.text != "}" && tok.text != "," {
+			break
+		}
+		if tok.text == "=" && f.isRequire(f.i+1) { // import x = require("y")
+			f.imports = append(f.imports, strings.Trim(f.tok(f.i+3).text, "\"'`"))
+			f.i += 5
+			break
+		}
+		f.i++
+	}
+	f.skipExpression(nil) // with { type: "json" }
+	f.accept(";")
+}
+
+// exportList handles export { a, b as c } [from "x"], export * from "x" and export = x
+func (f *jsFile) exportList() {
+	switch {
+	case f.accept("="):
+		if tok := f.tok(f.i); tok.kind == jsIdent {
+			f.exported[tok.text] = true
+		}
+		f.skipExpression(nil)
+	case f.accept("{"):
+		for f.i < len(f.tokens) && !f.accept("}") {
+			if f.accept(",") {
+				continue
+			}
+			f.accept("type")
+			f.exported[f.peek()] = true
+			f.i++
+			if f.accept("as") {
+				f.i++
+			}
+		}
+	default:
+		f.skipExpression(map[string]bool{"from": true})
+	}
+	if f.accept("from") && f.tok(f.i).kind == jsString {
+		f.imports = append(f.imports, strings.Trim(f.peek(), "\"'`"))
+		f.i++
+	}
+	f.accept(";")
+}
+
+// skipDecorators skips @decorator and @decorator(args) annotations
+func (f *jsFile) skipDecorators() {
+	for f.peek() == "@" {
+		f.i++
+		for f.tok(f.i).kind == jsIdent || f.peek() == "." {
+			f.i++
+		}
+		if f.peek() == "(" {
+			f.skipBalanced()
+		}
+	}
+}
+
+// jsContinues are the tokens that continue an expression across a line break
+// when they end a line or start the next one
+var jsContinues = map[string]bool{
+	".": true, "?.": true, ",": true, "=": true, "=>": true, "?": true, ":": true, "??": true,
+	"+": true, "-": true, "*": true, "/": true, "%": true, "**": true,
+	"&&": true, "||": true, "&": true, "|": true, "^": true, "<": true, "<=": true,
+	"==": true, "===": true, "!=": true, "!==": true, "(": true, "[": true, "{": true,
+	"+=": true, "-=": true, "*=": true, "/=": true, "instanceof": true, "in": true, "as": true,
+	"satisfies": true, "extends": true, "keyof": true, "typeof": true,
+}
+
+// skipExpression skips to the end of an expression: a semicolon, a token in
+// stop or a closing bracket at depth zero, or a line break that automatic
+// semicolon insertion would end the statement at
+func (f *jsFile) skipExpression(stop map[string]bool) {
+	f.scan(stop, false)
+}
+
+// typeText reads a type annotation up to a token in stop at depth zero and returns its source
+func (f *jsFile) typeText(stop map[string]bool) string {
+	start := f.i
+	f.scan(stop, true)
+	return f.text(start, f.i)
+}
+
+// scan implements skipExpression and typeText. Types also nest <>, and an
+// object type's "{" is part of the type wherever a type may start.
+func (f *jsFile) scan(stop map[string]bool, isType bool) {
+	depth := 0
+	start := f.i
+	for f.i < len(f.tokens) {
+		tok := f.tokens[f.i]
+		if depth == 0 {
+			if tok.text == ";" || stop[tok.text] && !(isType && tok.text == "{" && f.typeMayStart(start)) {
+				return
+			}
+			if tok.nl && f.i > start && !jsContinues[tok.text] && !jsContinues[f.tokens[f.i-1].text] {
+				return
+			}
+		}
+		switch tok.text {
+		case "(", "[", "{":
+			depth++
+		case ")", "]", "}":
+			if depth == 0 {
+				return
+			}
+			depth--
+		case "<":
+			if isType {
+				depth++
+			}
+		case ">":
+			if isType && depth > 0 {
+				depth--
+			} else if isType && stop[">"] {
+				return
+			}
+		}
+		f.i++
+	}
+}
+
+// typeMayStart reports whether a type starts at the current token (in a type begun at start)
+func (f *jsFile) typeMayStart(start int) bool {
+	if f.i == start {
+		return true
+	}
+	switch f.tokens[f.i-1].text {
+	case "|", "&", "=>", ":", "<", ",", "(", "keyof", "typeof", "?", "extends":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,21 @@
+package parser
+
+// Link is the post-parse pass over the elements of every language, once
+// all files are parsed: it fills EnumValues with the constants of enum
+// blocks, CalledBy of every function from the Calls of the others, the
+// subjects of tests and the implementations of bodiless Go declarations.
+// Rust impl blocks add their methods and traits to the type they are for,
+// generated Go code is linked to its .proto definitions, API endpoints to
+// their handlers, and Markdown sections to the code they mention.
+//
+// Run it after GoLoader.Link, whose Methods and Implements it builds on.
+func Link(elements []CodeElement) {
+	linkEnums(elements)
+	linkCallers(elements)
+	linkTests(elements)
+	linkImplementations(elements)
+	linkImpls(elements)
+	linkProtos(elements)
+	linkEndpoints(elements)
+	linkDocs(elements)
+}
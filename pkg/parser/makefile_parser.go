@@ -0,0 +1,240 @@
+package parser
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// MakefileParser parses Makefiles into one element per target, with its
+// prerequisites and recipe, so "how do I run X" finds the rule that does
+type MakefileParser struct{}
+
+// NewMakefileParser creates a new Makefile parser
+func NewMakefileParser() *MakefileParser {
+	return &MakefileParser{}
+}
+
+// SupportsFile checks if the parser supports this file
+func (p *MakefileParser) SupportsFile(filePath string) bool {
+	switch filepath.Base(filePath) {
+	case "Makefile", "makefile", "GNUmakefile":
+		return true
+	}
+	return filepath.Ext(filePath) == ".mk"
+}
+
+var (
+	// makeSpecialTarget matches the built-in targets (.PHONY, .DEFAULT_GOAL)
+	makeSpecialTarget = regexp.MustCompile(`^\.[A-Z_]+$`)
+
+	// makeSubMake matches a recipe running make on targets: $(MAKE) test, make -C dir build
+	makeSubMake = regexp.MustCompile(`(?:\$[({]MAKE[)}]|(?:^|[\s;&|@-])make)((?:[ \t]+[^\s;&|]+)*)`)
+)
+
+// Parse extracts the targets of a Makefile, and the Makefile itself as a
+// package element listing the files it includes
+func (p *MakefileParser) Parse(filePath string, content []byte) (*ParseResult, error) {
+	lines := strings.Split(strings.ReplaceAll(string(content), "\r\n", "\n"), "\n")
+
+	imports := make([]string, 0)
+	elements := make([]CodeElement, 0)
+	byName := make(map[string]int) // Index in elements of each target
+	rule := make([]int, 0)         // Targets of the rule whose recipe is being read
+	inDefine := false
+
+	for i := 0; i < len(lines); i++ {
+		start := i
+		line := lines[i]
+		for strings.HasSuffix(line, "\\") && i+1 < len(lines) {
+			i++
+			line = line[:len(line)-1] + " " + strings.TrimSpace(lines[i])
+		}
+
+		if inDefine {
+			if strings.HasPrefix(strings.TrimSpace(line), "endef") {
+				inDefine = false
+			}
+			continue
+		}
+		trimmed := strings.TrimSpace(line)
+		directive, rest, _ := strings.Cut(trimmed, " ")
+		conditional := false
+		switch directive {
+		case "ifeq", "ifneq", "ifdef", "ifndef", "else", "endif":
+			conditional = true // Choose between recipe lines without ending the recipe
+		}
+		if strings.HasPrefix(line, "\t") || conditional && len(rule) > 0 {
+			for _, t := range rule {
+				el := &elements[t]
+				el.Body += "\n" + strings.Join(lines[start:i+1], "\n")
+				el.EndLine = i + 1
+			}
+			continue
+		}
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") || conditional {
+			continue // Neither ends a recipe
+		}
+		rule = rule[:0]
+
+		switch directive {
+		case "define", "override", "export":
+			inDefine = directive == "define" || strings.HasPrefix(rest, "define")
+			continue
+		case "include", "-include", "sinclude":
+			imports = append(imports, strings.Fields(stripLineComment(rest, "#"))...)
+			continue
+		case "unexport", "vpath":
+			continue
+		}
+
+		targets, prerequisites, ok := makeRule(line)
+		if !ok {
+			continue // A variable assignment
+		}
+		doc := lineCommentAbove(lines, start, "#")
+		if _, help, found := strings.Cut(line, "##"); found { // target: deps ## Help text
+			doc = strings.TrimSpace(help)
+		}
+		for _, name := range targets {
+			if makeSpecialTarget.MatchString(name) {
+				continue
+			}
+			t, seen := byName[name]
+			if !seen {
+				t = len(elements)
+				byName[name] = t
+				elements = append(elements, CodeElement{
+					Type:          TypeTarget,
+					Name:          name,
+					ID:            filePath + ":" + name,
+					File:          filePath,
+					Line:          start + 1,
+					ImportPath:    filePath,
+					Prerequisites: make([]string, 0),
+					Exports:       true,
+					Language:      "make",
+					IndexedAt:     time.Now(),
+				})
+			}
+			el := &elements[t]
+			if seen {
+				el.Body += "\n"
+			}
+			el.Body += strings.Join(lines[start:i+1], "\n")
+			el.EndLine = i + 1
+			el.Prerequisites = append(el.Prerequisites, prerequisites...)
+			if el.Docstring == "" {
+				el.Docstring = doc
+			}
+			rule = append(rule, t)
+		}
+	}
+
+	// Calls: the targets of this Makefile a target needs, or runs with a
+	// sub-make; CalledBy lists them back
+	for i := range elements {
+		el := &elements[i]
+		seen := make(map[string]bool)
+		call := func(name string) {
+			if t, ok := byName[name]; ok && t != i && !seen[name] {
+				seen[name] = true
+				el.Calls = append(el.Calls, elements[t].ID)
+				elements[t].CalledBy = append(elements[t].CalledBy, el.ID)
+			}
+		}
+		for _, name := range el.Prerequisites {
+			call(name)
+		}
+		for _, line := range strings.Split(el.Body, "\n") {
+			if !strings.HasPrefix(line, "\t") {
+				continue
+			}
+			for _, m := range makeSubMake.FindAllStringSubmatch(line, -1) {
+				args := strings.Fields(m[1])
+				for _, arg := range args {
+					if arg == "-C" || arg == "-f" || strings.HasPrefix(arg, "--directory") || strings.HasPrefix(arg, "--file") {
+						args = nil // The targets of another Makefile
+					}
+				}
+				for _, arg := range args {
+					if !strings.HasPrefix(arg, "-") && !strings.Contains(arg, "=") {
+						call(arg)
+					}
+				}
+			}
+		}
+		el.Hash = HashCode(el.ID + "\n" + el.Body)
+	}
+
+	// The Makefile: its header comment, and what it includes
+	header := 0
+	for header < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[header]), "#") {
+		header++
+	}
+	makefile := CodeElement{
+		Type:            TypePackage,
+		Name:            filepath.Base(filePath),
+		ID:              filePath,
+		File:            filePath,
+		Line:            1,
+		EndLine:         max(header, 1),
+		Body:            strings.Join(lines[:header], "\n"),
+		Docstring:       lineCommentAbove(lines, header, "#"),
+		ImportPath:      filePath,
+		Files:           []string{filePath},
+		ExportedSymbols: len(elements),
+		Imports:         imports,
+		Language:        "make",
+		IndexedAt:       time.Now(),
+	}
+	makefile.Hash = HashCode(makefile.ID + "\n" + makefile.Body)
+
+	return &ParseResult{
+		Elements: append([]CodeElement{makefile}, elements...),
+		Errors:   make([]ParseError, 0),
+	}, nil
+}
+
+// makeRule splits a rule line ("a b: c d | e ; cmd") into its targets and
+// prerequisites, order-only ones included. It reports false for variable
+// assignments, target-specific ones included.
+func makeRule(line string) (targets, prerequisites []string, ok bool) {
+	line = stripLineComment(line, "#")
+	colon := -1
+	depth := 0
+	for j := 0; j < len(line) && colon < 0; j++ {
+		switch c := line[j]; {
+		case c == '(' || c == '{':
+			depth++
+		case c == ')' || c == '}':
+			depth--
+		case depth > 0:
+		case c == '=':
+			return nil, nil, false // X = a:b, X ?= y
+		case c == ':':
+			colon = j
+		}
+	}
+	if colon < 0 {
+		return nil, nil, false
+	}
+	rest := strings.TrimLeft(line[colon+1:], ":") // Double-colon rules
+	if strings.HasPrefix(rest, "=") {
+		return nil, nil, false // X := y, X ::= y
+	}
+	rest, _, _ = strings.Cut(rest, ";") // The inline recipe
+	if strings.Contains(rest, "=") {
+		return nil, nil, false // target: VAR = value
+	}
+	if _, pattern, static := strings.Cut(rest, ":"); static { // objs: %.o: %.c
+		rest = pattern
+	}
+	for _, name := range strings.Fields(rest) {
+		if name != "|" {
+			prerequisites = append(prerequisites, name)
+		}
+	}
+	return strings.Fields(line[:colon]), prerequisites, true
+}
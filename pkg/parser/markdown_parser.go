@@ -0,0 +1,334 @@
+package parser
+
+import (
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// MarkdownParser parses Markdown documentation into one section element
+// per heading, recording the code it mentions so linkDocs can tie the
+// prose to the elements it explains
+type MarkdownParser struct{}
+
+// NewMarkdownParser creates a new Markdown parser
+func NewMarkdownParser() *MarkdownParser {
+	return &MarkdownParser{}
+}
+
+// SupportsFile checks if the parser supports this file
+func (p *MarkdownParser) SupportsFile(filePath string) bool {
+	switch filepath.Ext(filePath) {
+	case ".md", ".markdown":
+		return true
+	}
+	return false
+}
+
+var (
+	// markdownHeading matches an ATX heading ("## Usage ##")
+	markdownHeading = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ \t]+(.*?))?(?:[ \t]+#+)?[ \t]*$`)
+
+	// markdownCode matches an inline code span
+	markdownCode = regexp.MustCompile("`([^`\n]+)`")
+
+	// markdownFileRef matches a file:line reference ("pkg/parser/types.go:42")
+	// or a line link ("types.go#L42")
+	markdownFileRef = regexp.MustCompile(`((?:\.{0,2}/)?(?:[\w.-]+/)*[\w-][\w.-]*\.[A-Za-z]\w*)(?::|#L)(\d+)`)
+
+	// markdownSymbol matches code spans that can name a symbol, not commands or expressions
+	markdownSymbol = regexp.MustCompile(`^[\w$.:/*&()]*[A-Za-z_][\w$.:/*&()]*$`)
+)
+
+// Parse splits a Markdown file into sections
+func (p *MarkdownParser) Parse(filePath string, content []byte) (*ParseResult, error) {
+	lines := strings.Split(strings.ReplaceAll(string(content), "\r\n", "\n"), "\n")
+
+	type heading struct {
+		line  int // 0-based index of the heading (for setext, its text line)
+		level int
+		text  string
+	}
+	headings := make([]heading, 0)
+	code := make([]bool, len(lines)) // Lines in fenced code blocks or front matter
+	fence := ""
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+		if i == 0 && trimmed == "---" { // YAML front matter
+			for code[i] = true; i+1 < len(lines); i++ {
+				code[i+1] = true
+				if strings.TrimSpace(lines[i+1]) == "---" {
+					i++
+					break
+				}
+			}
+			continue
+		}
+		if fence != "" {
+			code[i] = true
+			if strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]) == "" {
+				fence = ""
+			}
+			continue
+		}
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			code[i] = true
+			fence = trimmed[:3]
+			continue
+		}
+		if m := markdownHeading.FindStringSubmatch(line); m != nil {
+			headings = append(headings, heading{line: i, level: len(m[1]), text: strings.TrimSpace(m[2])})
+			continue
+		}
+		// Setext headings underline their text with === or ---
+		setext := trimmed != "" && (strings.Trim(trimmed, "=") == "" || len(trimmed) >= 2 && strings.Trim(trimmed, "-") == "")
+		if i > 0 && setext {
+			prev := strings.TrimSpace(lines[i-1])
+			if prev != "" && !code[i-1] && (len(headings) == 0 || headings[len(headings)-1].line != i-1) &&
+				!strings.HasPrefix(prev, "-") && !strings.HasPrefix(prev, "*") && !strings.HasPrefix(prev, ">") {
+				level := 1
+				if trimmed[0] == '-' {
+					level = 2
+				}
+				headings = append(headings, heading{line: i - 1, level: level, text: prev})
+			}
+		}
+	}
+
+	elements := make([]CodeElement, 0, len(headings)+1)
+	slugs := make(map[string]int)
+	section := func(name, id, context string, start, end int) CodeElement {
+		body := strings.TrimRight(strings.Join(lines[start:end], "\n"), "\n")
+		return CodeElement{
+			Type:       TypeSection,
+			Name:       name,
+			ID:         id,
+			File:       filePath,
+			Line:       start + 1,
+			EndLine:    start + len(strings.Split(body, "\n")),
+			ImportPath: filePath,
+			Context:    context,
+			Body:       body,
+			Mentions:   markdownMentions(lines[start:end], code[start:end]),
+			Language:   "markdown",
+			IndexedAt:  time.Now(),
+		}
+	}
+
+	// Text before the first heading belongs to the document itself
+	first := len(lines)
+	if len(headings) > 0 {
+		first = headings[0].line
+	}
+	if strings.TrimSpace(strings.Join(lines[:first], "")) != "" {
+		name := strings.TrimSuffix(filepath.Base(filePath), filepath.Ext(filePath))
+		el := section(name, filePath, "", 0, first)
+		el.Docstring = markdownParagraph(lines[:first], code[:first])
+		elements = append(elements, el)
+	}
+
+	parents := make([]int, 0) // Indexes in elements of the enclosing sections
+	levels := make([]int, 0)
+	for i, h := range headings {
+		end := len(lines)
+		if i+1 < len(headings) {
+			end = headings[i+1].line
+		}
+		for len(levels) > 0 && levels[len(levels)-1] >= h.level {
+			parents, levels = parents[:len(parents)-1], levels[:len(levels)-1]
+		}
+		context := ""
+		if len(parents) > 0 {
+			context = elements[parents[len(parents)-1]].ID
+		}
+
+		slug := markdownSlug(h.text)
+		if n := slugs[slug]; n > 0 {
+			slugs[slug]++
+			slug += "-" + strconv.Itoa(n)
+		} else {
+			slugs[slug] = 1
+		}
+		el := section(h.text, filePath+"#"+slug, context, h.line, end)
+		bodyStart := h.line + 1
+		if bodyStart < end && !markdownHeading.MatchString(lines[h.line]) {
+			bodyStart++ // The setext underline
+		}
+		el.Docstring = markdownParagraph(lines[bodyStart:end], code[bodyStart:end])
+
+		parents = append(parents, len(elements))
+		levels = append(levels, h.level)
+		elements = append(elements, el)
+	}
+	for i := range elements {
+		elements[i].Hash = HashCode(elements[i].ID + "\n" + elements[i].Body)
+	}
+
+	return &ParseResult{
+		Elements: elements,
+		Errors:   make([]ParseError, 0),
+	}, nil
+}
+
+// markdownSlug returns the anchor GitHub gives a heading: lower case,
+// punctuation dropped, spaces as hyphens
+func markdownSlug(text string) string {
+	var sb strings.Builder
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case r == ' ':
+			sb.WriteByte('-')
+		case r == '-' || r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r):
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// markdownParagraph returns the first paragraph of text outside code blocks
+func markdownParagraph(lines []string, code []bool) string {
+	paragraph := make([]string, 0)
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		if code[i] || line == "" {
+			if len(paragraph) > 0 {
+				break
+			}
+			continue
+		}
+		paragraph = append(paragraph, line)
+	}
+	return strings.Join(paragraph, "\n")
+}
+
+// markdownMentions returns the code spans that may name a symbol and the
+// file:line references of the lines, in order of appearance, without duplicates
+func markdownMentions(lines []string, code []bool) []string {
+	seen := make(map[string]bool)
+	mentions := make([]string, 0)
+	add := func(mention string) {
+		if !seen[mention] {
+			seen[mention] = true
+			mentions = append(mentions, mention)
+		}
+	}
+	for i, line := range lines {
+		if code[i] {
+			continue
+		}
+		for _, m := range markdownFileRef.FindAllStringSubmatch(line, -1) {
+			add(m[1] + ":" + m[2])
+		}
+		for _, m := range markdownCode.FindAllStringSubmatch(line, -1) {
+			span := strings.TrimSpace(m[1])
+			if len(span) >= 2 && markdownSymbol.MatchString(span) && !markdownFileRef.MatchString(span) {
+				add(span)
+			}
+		}
+	}
+	return mentions
+}
+
+// symbolSuffixes returns the ways an ID or mention can be written: itself
+// and what follows each separator ("pkg/indexer.Indexer.Search",
+// "indexer.Indexer.Search", "Indexer.Search", "Search"), with pointer
+// receivers and argument lists dropped
+func symbolSuffixes(s string) []string {
+	if open := strings.LastIndexByte(s, '('); open > 0 && strings.HasSuffix(s, ")") && s[open-1] != '.' {
+		s = s[:open] // Search(), Java method(String)
+	}
+	s = strings.NewReplacer("(*", "", "(", "", ")", "", "*", "", "&", "").Replace(s)
+	suffixes := []string{s}
+	for i := 1; i < len(s); i++ {
+		if strings.IndexByte("./:#", s[i-1]) >= 0 && strings.IndexByte("./:#", s[i]) < 0 {
+			suffixes = append(suffixes, s[i:])
+		}
+	}
+	return suffixes
+}
+
+// linkDocs resolves the mentions of documentation sections: a code span
+// names the element whose ID it ends with, when no other element does, and a
+// file:line reference the innermost element spanning that line. Sections
+// list the elements in Documents, the elements the sections in DocumentedIn.
+func linkDocs(elements []CodeElement) {
+	bySuffix := make(map[string][]*CodeElement)
+	byFile := make(map[string][]*CodeElement)
+	sections := make([]*CodeElement, 0)
+	for i := range elements {
+		el := &elements[i]
+		if el.Type == TypeSection {
+			if len(el.Mentions) > 0 {
+				sections = append(sections, el)
+			}
+			continue
+		}
+		seen := make(map[string]bool)
+		for _, suffix := range append(symbolSuffixes(el.ID), symbolSuffixes(el.Name)...) {
+			if !seen[suffix] {
+				seen[suffix] = true
+				bySuffix[suffix] = append(bySuffix[suffix], el)
+			}
+		}
+		if el.Type != TypePackage {
+			byFile[el.File] = append(byFile[el.File], el)
+		}
+	}
+	if len(sections) == 0 {
+		return
+	}
+
+	for _, section := range sections {
+		linked := make(map[*CodeElement]bool)
+		for _, mention := range section.Mentions {
+			var target *CodeElement
+			if m := markdownFileRef.FindStringSubmatch(mention); m != nil && m[0] == mention {
+				target = elementAtLine(byFile, filepath.Dir(section.File), m[1], m[2])
+			} else if candidates := bySuffix[symbolSuffixes(mention)[0]]; len(candidates) == 1 {
+				target = candidates[0]
+			}
+			if target == nil || linked[target] {
+				continue
+			}
+			linked[target] = true
+			section.Documents = append(section.Documents, target.ID)
+			target.DocumentedIn = append(target.DocumentedIn, section.ID)
+		}
+	}
+}
+
+// elementAtLine returns the innermost element spanning a line of a file
+// referenced from a document in dir: relative to the document, to the
+// root, or as the only indexed file with that path suffix
+func elementAtLine(byFile map[string][]*CodeElement, dir, file, line string) *CodeElement {
+	n, _ := strconv.Atoi(line)
+	candidates := byFile[filepath.ToSlash(filepath.Join(dir, file))]
+	if candidates == nil {
+		candidates = byFile[filepath.ToSlash(filepath.Clean(file))]
+	}
+	if candidates == nil {
+		suffix := "/" + strings.TrimLeft(filepath.ToSlash(filepath.Clean(file)), "./")
+		matched := ""
+		for path := range byFile {
+			if strings.HasSuffix(path, suffix) {
+				if matched != "" {
+					return nil // Ambiguous
+				}
+				matched = path
+			}
+		}
+		candidates = byFile[matched]
+	}
+
+	var target *CodeElement
+	for _, el := range candidates {
+		if el.Line <= n && n <= el.EndLine && (target == nil || el.EndLine-el.Line < target.EndLine-target.Line) {
+			target = el
+		}
+	}
+	return target
+}
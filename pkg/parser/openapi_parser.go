@@ -0,0 +1,552 @@
+package parser
+
+import (
+	"bytes"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// OpenAPIParser parses OpenAPI (and Swagger 2) specifications into
+// endpoint and schema elements, and standalone JSON Schema files into
+// schema elements. Other JSON and YAML files yield nothing.
+type OpenAPIParser struct{}
+
+// NewOpenAPIParser creates a new OpenAPI parser
+func NewOpenAPIParser() *OpenAPIParser {
+	return &OpenAPIParser{}
+}
+
+// SupportsFile checks if the parser supports this file
+func (p *OpenAPIParser) SupportsFile(filePath string) bool {
+	switch filepath.Ext(filePath) {
+	case ".json", ".yaml", ".yml":
+		return true
+	}
+	return false
+}
+
+// openAPIMethods are the operations of a path item, in the order the specification lists them
+var openAPIMethods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// Parse parses a specification, if the file is one
+func (p *OpenAPIParser) Parse(filePath string, content []byte) (*ParseResult, error) {
+	result := &ParseResult{
+		Elements: make([]CodeElement, 0),
+		Errors:   make([]ParseError, 0),
+	}
+	base := strings.ToLower(filepath.Base(filePath))
+	isSchemaFile := strings.Contains(base, ".schema.")
+	if !isSchemaFile && !bytes.Contains(content, []byte("openapi")) && !bytes.Contains(content, []byte("swagger")) &&
+		!bytes.Contains(content, []byte("$schema")) {
+		return result, nil // Not a specification; spare parsing every config file
+	}
+
+	var root *specNode
+	if filepath.Ext(filePath) == ".json" {
+		var err error
+		if root, err = parseJSONDocument(content); err != nil {
+			result.Errors = append(result.Errors, ParseError{File: filePath, Message: err.Error()})
+			return result, nil
+		}
+	} else {
+		root = parseYAMLDocument(content)
+	}
+	if root == nil || root.kind != specMap {
+		return result, nil
+	}
+
+	s := &specFile{root: root, path: filePath, lines: strings.Split(string(content), "\n")}
+	switch {
+	case root.str("openapi") != "" || root.str("swagger") != "":
+		s.language = "openapi"
+		s.openAPI()
+	case isJSONSchema(root, isSchemaFile):
+		s.language = "jsonschema"
+		s.jsonSchema()
+	}
+	for i := range s.elements {
+		el := &s.elements[i]
+		el.Hash = HashCode(el.ID + "\n" + el.Body)
+	}
+	result.Elements = s.elements
+	return result, nil
+}
+
+// isJSONSchema reports whether a document is a JSON Schema rather than a
+// file naming its schema in $schema (tsconfig.json, renovate.json): a
+// schema names the json-schema.org dialect, or the file is named
+// *.schema.json, and it defines a type, properties or subschemas
+func isJSONSchema(root *specNode, isSchemaFile bool) bool {
+	if !isSchemaFile && !strings.Contains(root.str("$schema"), "json-schema.org/") {
+		return false
+	}
+	for _, key := range []string{"type", "properties", "$defs", "definitions"} {
+		if root.get(key) != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// specFile is the state of extracting elements from one specification
+type specFile struct {
+	root     *specNode
+	path     string
+	lines    []string
+	language string
+	elements []CodeElement
+}
+
+// element creates an element spanning the lines of node n
+func (s *specFile) element(typ ElementType, name, id string, n *specNode) CodeElement {
+	end := n.end
+	if end > len(s.lines) {
+		end = len(s.lines)
+	}
+	return CodeElement{
+		Type:       typ,
+		Name:       name,
+		ID:         id,
+		File:       s.path,
+		Line:       n.line,
+		EndLine:    end,
+		ImportPath: s.path,
+		Body:       strings.Join(s.lines[n.line-1:end], "\n"),
+		Exports:    true,
+		Language:   s.language,
+		IndexedAt:  time.Now(),
+	}
+}
+
+// openAPI extracts the endpoints of paths and the schemas of
+// components/schemas (OpenAPI 3) or definitions (Swagger 2)
+func (s *specFile) openAPI() {
+	// The server routes paths below the base path
+	basePath := s.root.str("basePath")
+	if servers := s.root.get("servers"); servers != nil && len(servers.items) > 0 {
+		if u, err := url.Parse(servers.items[0].str("url")); err == nil && !strings.Contains(u.Path, "{") {
+			basePath = u.Path
+		}
+	}
+	basePath = strings.TrimSuffix(basePath, "/")
+
+	paths := s.root.get("paths")
+	for _, path := range pathsKeys(paths) {
+		item := s.resolve(paths.get(path))
+		for _, method := range openAPIMethods {
+			if op := item.get(method); op != nil {
+				s.endpoint(strings.ToUpper(method), path, basePath, item, op)
+			}
+		}
+	}
+
+	for _, section := range []*specNode{s.root.get("components").get("schemas"), s.root.get("definitions")} {
+		if section == nil {
+			continue
+		}
+		for _, name := range section.keys {
+			s.schema(name, s.path+"#"+name, section.get(name))
+		}
+	}
+}
+
+// pathsKeys returns the paths of the paths object, without extensions (x-)
+func pathsKeys(paths *specNode) []string {
+	keys := make([]string, 0)
+	if paths == nil {
+		return keys
+	}
+	for _, key := range paths.keys {
+		if strings.HasPrefix(key, "/") {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// resolve follows a local $ref
+func (s *specFile) resolve(n *specNode) *specNode {
+	for i := 0; i < 8 && n.str("$ref") != ""; i++ {
+		target := s.root.pointer(n.str("$ref"))
+		if target == nil {
+			break
+		}
+		n = target
+	}
+	return n
+}
+
+// endpoint extracts an operation of a path
+func (s *specFile) endpoint(method, path, basePath string, item, op *specNode) {
+	name := method + " " + path
+	el := s.element(TypeEndpoint, name, s.path+"#"+name, op)
+	el.Method = method
+	el.Route = basePath + path
+	el.OperationID = op.str("operationId")
+	el.Docstring = strings.TrimSpace(op.str("summary") + "\n\n" + op.str("description"))
+
+	// Operation parameters override those of the path item with the same name and location
+	el.Params = make([]Parameter, 0)
+	seen := make(map[string]int)
+	for _, list := range []*specNode{item.get("parameters"), op.get("parameters")} {
+		if list == nil {
+			continue
+		}
+		for _, param := range list.items {
+			param = s.resolve(param)
+			in := param.str("in")
+			typ := s.schemaType(param.get("schema"))
+			if typ == "" {
+				typ = param.str("type") // Swagger 2
+			}
+			parameter := Parameter{
+				Name:     param.str("name"),
+				Type:     strings.TrimSpace(in + " " + typ),
+				Optional: param.str("required") != "true" && in != "path",
+			}
+			key := in + " " + parameter.Name
+			if i, ok := seen[key]; ok {
+				el.Params[i] = parameter
+				continue
+			}
+			seen[key] = len(el.Params)
+			el.Params = append(el.Params, parameter)
+		}
+	}
+	if body := s.resolve(op.get("requestBody")); body != nil {
+		el.Params = append(el.Params, Parameter{
+			Name:     "body",
+			Type:     "body " + s.contentType(body.get("content")),
+			Optional: body.str("required") != "true",
+		})
+	}
+
+	// Responses: "200 User, 404 Error"
+	if responses := op.get("responses"); responses != nil {
+		returns := make([]string, 0, len(responses.keys))
+		for _, code := range responses.keys {
+			response := s.resolve(responses.get(code))
+			typ := s.contentType(response.get("content"))
+			if typ == "" {
+				typ = s.schemaType(response.get("schema")) // Swagger 2
+			}
+			returns = append(returns, strings.TrimSpace(code+" "+typ))
+		}
+		el.Returns = strings.Join(returns, ", ")
+	}
+	s.elements = append(s.elements, el)
+}
+
+// contentType returns the schema type of a content map, preferring JSON
+func (s *specFile) contentType(content *specNode) string {
+	if content == nil || len(content.keys) == 0 {
+		return ""
+	}
+	mediaType := content.keys[0]
+	for _, key := range content.keys {
+		if strings.Contains(key, "json") {
+			mediaType = key
+			break
+		}
+	}
+	return s.schemaType(content.get(mediaType).get("schema"))
+}
+
+// schemaType renders a schema as a type: the name of a referenced schema,
+// []Item for arrays, map[string]V for maps, A|B for alternatives
+func (s *specFile) schemaType(schema *specNode) string {
+	if schema == nil {
+		return ""
+	}
+	if ref := schema.str("$ref"); ref != "" {
+		return ref[strings.LastIndexAny(ref, "/#")+1:]
+	}
+	for _, combiner := range []string{"oneOf", "anyOf", "allOf"} {
+		if alternatives := schema.get(combiner); alternatives != nil && len(alternatives.items) > 0 {
+			types := make([]string, len(alternatives.items))
+			for i, alternative := range alternatives.items {
+				types[i] = s.schemaType(alternative)
+			}
+			sep := "|"
+			if combiner == "allOf" {
+				sep = "&"
+			}
+			return strings.Join(types, sep)
+		}
+	}
+
+	typ := schema.str("type")
+	if types := schema.get("type"); types != nil && types.kind == specList { // ["string", "null"]
+		names := make([]string, len(types.items))
+		for i, t := range types.items {
+			names[i] = t.value
+		}
+		typ = strings.Join(names, "|")
+	}
+	switch {
+	case typ == "array":
+		return "[]" + s.schemaType(schema.get("items"))
+	case typ == "object" && schema.get("additionalProperties") != nil && schema.get("additionalProperties").kind == specMap:
+		return "map[string]" + s.schemaType(schema.get("additionalProperties"))
+	case typ == "" && schema.get("properties") != nil:
+		return "object"
+	}
+	if format := schema.str("format"); format != "" {
+		typ += "(" + format + ")"
+	}
+	return typ
+}
+
+// schema extracts a named schema with its properties as fields
+func (s *specFile) schema(name, id string, schema *specNode) {
+	el := s.element(TypeSchema, name, id, schema)
+	el.Docstring = schema.str("description")
+	if el.Docstring == "" && schema.str("title") != name {
+		el.Docstring = schema.str("title")
+	}
+	if values := schema.get("enum"); values != nil {
+		for _, value := range values.items {
+			el.EnumValues = append(el.EnumValues, value.value)
+		}
+	}
+
+	// allOf composes: referenced schemas are extended, inline ones add properties
+	parts := []*specNode{schema}
+	if allOf := schema.get("allOf"); allOf != nil {
+		for _, part := range allOf.items {
+			if ref := part.str("$ref"); ref != "" {
+				if el.Extends == "" {
+					el.Extends = s.schemaType(part)
+				} else {
+					el.Implements = append(el.Implements, s.schemaType(part))
+				}
+				continue
+			}
+			parts = append(parts, part)
+		}
+	}
+	for _, part := range parts {
+		required := make(map[string]bool)
+		if list := part.get("required"); list != nil {
+			for _, item := range list.items {
+				required[item.value] = true
+			}
+		}
+		properties := part.get("properties")
+		if properties == nil {
+			continue
+		}
+		for _, prop := range properties.keys {
+			field := Field{Name: prop, Type: s.schemaType(properties.get(prop)), Docstring: properties.get(prop).str("description")}
+			if required[prop] {
+				field.Tag = "required"
+			}
+			el.Fields = append(el.Fields, field)
+		}
+	}
+	s.elements = append(s.elements, el)
+}
+
+// jsonSchema extracts a standalone JSON Schema and the schemas it defines
+func (s *specFile) jsonSchema() {
+	name := s.root.str("title")
+	if name == "" {
+		name = filepath.Base(s.path)
+		name = name[:strings.IndexByte(name, '.')]
+	}
+	s.schema(name, s.path, s.root)
+	for _, key := range []string{"$defs", "definitions"} {
+		defs := s.root.get(key)
+		if defs == nil {
+			continue
+		}
+		for _, def := range defs.keys {
+			s.schema(def, s.path+"#"+def, defs.get(def))
+		}
+	}
+}
+
+// goRoute matches a route registration of net/http, chi, gorilla/mux, gin
+// or echo: the method or registering function, the pattern, the handler and
+// a gorilla .Methods("GET")
+var goRoute = regexp.MustCompile(`\.(HandleFunc|Handle|Get|Post|Put|Patch|Delete|Head|Options|GET|POST|PUT|PATCH|DELETE|HEAD|OPTIONS|Any)\(\s*"([^"]*)"\s*,\s*(?:http\.HandlerFunc\()?([\w.]+)[^\n]*?(?:\.Methods\("([A-Za-z]+)")?\s*$`)
+
+// routeRegistration is a route a Go function registers
+type routeRegistration struct {
+	method  string // "" for any
+	route   string // Normalized: parameters are {}
+	handler *CodeElement
+}
+
+// normalizeRoute rewrites path parameters ({id}, :id, <id>, *) as {} and drops a trailing slash
+func normalizeRoute(route string) string {
+	segments := strings.Split(strings.Trim(route, "/"), "/")
+	for i, segment := range segments {
+		if segment != "" && strings.IndexByte("{:<*", segment[0]) >= 0 {
+			segments[i] = "{}"
+		}
+	}
+	return "/" + strings.Join(segments, "/")
+}
+
+// operationKey reduces an operationId or Go name to compare them: "get-user" and "GetUser" are alike
+func operationKey(name string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", "_", "", ".", "", " ", "").Replace(name))
+}
+
+// goReceiver returns the receiver type of a Go method ("pkg.Server"), or ""
+// for a plain function
+func goReceiver(fn *CodeElement) string {
+	dot := strings.LastIndexByte(fn.Name, '.')
+	if dot < 0 {
+		return ""
+	}
+	return fn.ImportPath + "." + fn.Name[:dot]
+}
+
+// linkEndpoints links API endpoints to the Go functions serving them: the
+// handlers registered for the route, and the function named by the
+// operationId when exactly one is. A method named by the operationId only
+// counts when its type serves routes, or implements an interface declaring
+// the operation (a generated ServerInterface). Handlers get the endpoint as
+// their Declaration, endpoints the handlers in ImplementedBy.
+func linkEndpoints(elements []CodeElement) {
+	endpoints := make([]*CodeElement, 0)
+	functions := make([]*CodeElement, 0)
+	byOperation := make(map[string][]*CodeElement)
+	operations := make(map[string]map[string]bool) // Operation keys of the methods of each interface, by name
+	implements := make(map[string][]string)        // Interfaces each type implements, by receiver
+	for i := range elements {
+		el := &elements[i]
+		switch {
+		case el.Type == TypeEndpoint:
+			endpoints = append(endpoints, el)
+		case el.Language != "go":
+		case el.Type == TypeFunction && !strings.HasSuffix(el.File, "_test.go"):
+			functions = append(functions, el)
+			name := el.Name[strings.LastIndexByte(el.Name, '.')+1:]
+			byOperation[operationKey(name)] = append(byOperation[operationKey(name)], el)
+		case el.Type == TypeInterface:
+			keys := make(map[string]bool)
+			for _, method := range el.Methods {
+				keys[operationKey(method)] = true
+			}
+			operations[el.Name] = keys
+		case len(el.Implements) > 0:
+			implements[el.ImportPath+"."+el.Name] = el.Implements
+		}
+	}
+	if len(endpoints) == 0 {
+		return
+	}
+
+	// Handlers are resolved in the registering package first
+	handler := func(registrar *CodeElement, expr string) *CodeElement {
+		name := expr[strings.LastIndexByte(expr, '.')+1:]
+		var local, global []*CodeElement
+		for _, fn := range functions {
+			if fn.Name != name && !strings.HasSuffix(fn.Name, "."+name) {
+				continue
+			}
+			global = append(global, fn)
+			if fn.ImportPath == registrar.ImportPath {
+				local = append(local, fn)
+			}
+		}
+		switch {
+		case len(local) == 1:
+			return local[0]
+		case len(local) == 0 && len(global) == 1:
+			return global[0]
+		}
+		return nil
+	}
+	routes := make([]routeRegistration, 0)
+	for _, fn := range functions {
+		for _, line := range strings.Split(fn.Body, "\n") {
+			m := goRoute.FindStringSubmatch(line)
+			if m == nil {
+				continue
+			}
+			r := routeRegistration{route: m[2], method: strings.ToUpper(m[4])}
+			switch m[1] {
+			case "HandleFunc", "Handle", "Any":
+				if method, pattern, ok := strings.Cut(m[2], " "); ok { // Go 1.22 "GET /users/{id}"
+					r.method, r.route = method, strings.TrimSpace(pattern)
+				}
+			default:
+				r.method = strings.ToUpper(m[1])
+			}
+			if !strings.HasPrefix(r.route, "/") {
+				continue
+			}
+			if r.handler = handler(fn, m[3]); r.handler != nil {
+				r.route = normalizeRoute(r.route)
+				routes = append(routes, r)
+			}
+		}
+	}
+
+	serving := make(map[string]bool) // Receivers of registered handlers
+	for _, r := range routes {
+		serving[goReceiver(r.handler)] = true
+	}
+	declares := func(receiver, key string) bool {
+		for _, iface := range implements[receiver] {
+			if operations[iface[strings.LastIndexByte(iface, '.')+1:]][key] {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, endpoint := range endpoints {
+		handlers := make([]*CodeElement, 0)
+		if endpoint.OperationID != "" {
+			key := operationKey(endpoint.OperationID)
+			candidates := make([]*CodeElement, 0)
+			for _, fn := range byOperation[key] {
+				if receiver := goReceiver(fn); receiver == "" || serving[receiver] || declares(receiver, key) {
+					candidates = append(candidates, fn)
+				}
+			}
+			if len(candidates) == 1 {
+				handlers = append(handlers, candidates[0])
+			}
+		}
+
+		// A route registered in a group (chi's r.Route("/api", ...)) matches the end of the path
+		route := normalizeRoute(endpoint.Route)
+		var exact, suffix []*CodeElement
+		for _, r := range routes {
+			if r.method != "" && r.method != endpoint.Method {
+				continue
+			}
+			switch {
+			case r.route == route || r.route == normalizeRoute(strings.TrimPrefix(endpoint.Name, endpoint.Method+" ")):
+				exact = append(exact, r.handler)
+			case r.route != "/" && strings.HasSuffix(route, r.route):
+				suffix = append(suffix, r.handler)
+			}
+		}
+		handlers = append(handlers, exact...)
+		if len(exact) == 0 && len(suffix) == 1 {
+			handlers = append(handlers, suffix...)
+		}
+
+		sort.SliceStable(handlers, func(i, j int) bool { return handlers[i].ID < handlers[j].ID })
+		for i, h := range handlers {
+			if i > 0 && handlers[i-1] == h {
+				continue
+			}
+			if h.Declaration == "" {
+				h.Declaration = endpoint.ID
+			}
+			endpoint.ImplementedBy = append(endpoint.ImplementedBy, h.ID)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package parser
+
+// MergePackages merges the package elements sharing an ID into the first
+// of them. Parsers that see one file at a time (Java) emit a package element
+// per file; merged, it lists every file and counts every exported symbol.
+func MergePackages(elements []CodeElement) []CodeElement {
+	first := make(map[string]int)
+	merged := elements[:0]
+	for _, el := range elements {
+		if el.Type != TypePackage {
+			merged = append(merged, el)
+			continue
+		}
+		i, seen := first[el.ID]
+		if !seen {
+			first[el.ID] = len(merged)
+			merged = append(merged, el)
+			continue
+		}
+
+		pkg := &merged[i]
+		pkg.Files = append(pkg.Files, el.Files...)
+		pkg.ExportedSymbols += el.ExportedSymbols
+		if pkg.Docstring == "" && el.Docstring != "" {
+			// The documented file (package-info.java) speaks for the package
+			files, exported := pkg.Files, pkg.ExportedSymbols
+			*pkg = el
+			pkg.Files, pkg.ExportedSymbols = files, exported
+		}
+	}
+	return merged
+}
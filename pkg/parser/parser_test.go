@@ -0,0 +1,102 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// parserSamples are a small source file for each parser, covering the
+// constructs whose headers a truncated file cuts short
+var parserSamples = []struct {
+	file string
+	src  string
+}{
+	{"a.go", "package a\n\n// F doc\nfunc F[T any](a int, b ...string) (int, error) { return g(a), nil }\n\ntype S struct {\n\tA int `json:\"a\"`\n}\n\nfunc (s *S) M() { var h = func() {}; h() }\n"},
+	{"a.ts", "import { x } from \"./x\";\n/** Doc */\nexport class Foo<T> extends Bar implements Baz {\n  constructor(public b: string) { super(); }\n  async get(id: number): Promise<T> { return this.load(id); }\n}\nexport const arrow = (x: number) => <div>{x}</div>;\n"},
+	{"A.java", "package a;\nimport java.util.List;\n/** Doc */\n@Service\npublic class A<T> extends B implements C<D> {\n  @Override public List<String> run(int a, String... rest) { return helper(a); }\n  enum Kind { ONE, TWO }\n}\n"},
+	{"lib.rs", "use std::fmt;\n/// Doc\n#[derive(Debug)]\npub struct P<T> { pub x: T }\npub trait Shape { fn area(&self) -> f64; }\nimpl<T> Shape for P<T> { fn area(&self) -> f64 { 1.0 } }\npub async fn run(p: &P<f64>) -> f64 { p.area() }\n"},
+	{"a.proto", "syntax = \"proto3\";\npackage demo;\nmessage User {\n  int64 id = 1; // Id\n  string name = 2 [deprecated = true];\n  oneof c { string email = 3; }\n}\nservice Users { rpc Get(User) returns (stream User); }\n"},
+	{"m.py", "\"\"\"Doc\"\"\"\nimport os\n@dataclass\nclass A(Base, metaclass=M):\n    def f[T](self, x: int = 1) -> int:\n        return g(x)\nasync def g(x):\n    yield x\n"},
+	{"s.sql", "-- Users\nCREATE TABLE users (id INT PRIMARY KEY, name TEXT NOT NULL);\nCREATE INDEX users_name ON users (name);\nCREATE FUNCTION f() RETURNS int AS $$ BEGIN RETURN 1; END $$ LANGUAGE plpgsql;\n"},
+	{"README.md", "# Title\n\nSee `F` and [the code](a.go).\n\n## Usage\n\n```go\nF()\n```\n"},
+	{"api.yaml", "openapi: 3.0.0\npaths:\n  /users/{id}:\n    get:\n      operationId: getUser\n      parameters:\n        - name: id\n          in: path\n"},
+	{"run.sh", "#!/bin/sh\n# Doc\nbuild() {\n  cat <<EOF\n}\nEOF\n  go build ./...\n}\nbuild\n"},
+	{"Makefile", "# Doc\n.PHONY: all\nall: build ## Build it\n\t$(MAKE) test\nbuild:\n\tgo build ./...\n"},
+	{"a.rb", "class A < B\n  def run(x)\n    x\n  end\nend\n"},
+}
+
+// allParsers returns every parser, the regex ones of the default profiles included
+func allParsers(t *testing.T) []Parser {
+	t.Helper()
+	parsers := []Parser{
+		NewGoParser(), NewJSParser(), NewJavaParser(), NewRustParser(), NewProtoParser(),
+		NewPythonParser(), NewSQLParser(), NewMarkdownParser(), NewOpenAPIParser(),
+		NewShellParser(), NewMakefileParser(),
+	}
+	for _, profile := range DefaultProfiles() {
+		p, err := NewRegexParser(profile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		parsers = append(parsers, p)
+	}
+	return parsers
+}
+
+// parse runs a parser, turning a panic into an error
+func parse(p Parser, file, src string) (result *ParseResult, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return p.Parse(file, []byte(src))
+}
+
+func TestParsersTruncatedInput(t *testing.T) {
+	parsers := allParsers(t)
+	for _, sample := range parserSamples {
+		t.Run(sample.file, func(t *testing.T) {
+			supported := false
+			for _, p := range parsers {
+				if !p.SupportsFile(sample.file) {
+					continue
+				}
+				supported = true
+				for n := 0; n <= len(sample.src); n++ {
+					if _, err := parse(p, sample.file, sample.src[:n]); err != nil && strings.HasPrefix(err.Error(), "panic:") {
+						t.Fatalf("%T on the first %d bytes %q: %v", p, n, sample.src[:n], err)
+					}
+				}
+			}
+			if !supported {
+				t.Fatalf("no parser supports %s", sample.file)
+			}
+		})
+	}
+}
+
+func TestParsersUniqueIDs(t *testing.T) {
+	parsers := allParsers(t)
+	for _, sample := range parserSamples {
+		t.Run(sample.file, func(t *testing.T) {
+			for _, p := range parsers {
+				if !p.SupportsFile(sample.file) {
+					continue
+				}
+				result, err := parse(p, sample.file, sample.src)
+				if err != nil {
+					t.Fatalf("%T: %v", p, err)
+				}
+				seen := make(map[string]bool)
+				for _, el := range result.Elements {
+					if seen[el.ID] {
+						t.Errorf("%T: duplicate ID %s", p, el.ID)
+					}
+					seen[el.ID] = true
+				}
+			}
+		})
+	}
+}
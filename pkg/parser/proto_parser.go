@@ -0,0 +1,515 @@
+package parser
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ProtoParser parses Protocol Buffers definitions: messages with their
+// numbered fields, enums, services and their RPC methods
+type ProtoParser struct{}
+
+// NewProtoParser creates a new Protocol Buffers parser
+func NewProtoParser() *ProtoParser {
+	return &ProtoParser{}
+}
+
+// SupportsFile checks if the parser supports this file
+func (p *ProtoParser) SupportsFile(filePath string) bool {
+	return filepath.Ext(filePath) == ".proto"
+}
+
+// Parse parses a .proto file and extracts its definitions
+func (p *ProtoParser) Parse(filePath string, content []byte) (*ParseResult, error) {
+	src := string(content)
+	f := &protoFile{
+		tokenCursor: newTokenCursor(tokenizeProto(src), src, protoToken{srcToken: srcToken{pos: len(src), end: len(src)}}),
+		path:        filePath,
+	}
+	f.body("")
+
+	for i := range f.elements {
+		el := &f.elements[i]
+		el.ID = el.Name
+		if f.pkg != "" {
+			el.ID = f.pkg + "." + el.Name
+		}
+		el.ImportPath = f.pkg
+		el.GoPackage = f.goPackage
+		el.Imports = f.imports
+		el.Hash = HashCode(el.ID + "\n" + el.Body)
+	}
+	return &ParseResult{
+		Elements: f.elements,
+		Errors:   make([]ParseError, 0),
+	}, nil
+}
+
+// protoToken is a token with its byte range; doc holds the comments
+// directly above it (trailing comments of the previous line excluded), and
+// trailing the comments after it on its line
+type protoToken struct {
+	srcToken
+	doc      string
+	trailing string
+}
+
+// tokenizeProto splits a .proto file into tokens. Qualified names
+// (google.protobuf.Timestamp, .pkg.Msg) are single tokens.
+func tokenizeProto(src string) []protoToken {
+	tokens := make([]protoToken, 0, len(src)/4)
+	doc := make([]string, 0)
+	lastLine := -1 // Line of the previous token, whose trailing comment is not a doc
+	line := 0
+	for pos := 0; pos < len(src); {
+		c := src[pos]
+		start := pos
+		switch {
+		case c == '\n':
+			if pos > 0 && strings.TrimRight(src[strings.LastIndexByte(src[:pos], '\n')+1:pos], " \t\r") == "" {
+				doc = doc[:0] // A blank line detaches the comments above it
+			}
+			line++
+			pos++
+			continue
+		case c == ' ' || c == '\t' || c == '\r':
+			pos++
+			continue
+		case strings.HasPrefix(src[pos:], "//"):
+			end := strings.IndexByte(src[pos:], '\n')
+			if end < 0 {
+				end = len(src) - pos
+			}
+			text := strings.TrimPrefix(strings.TrimRight(src[pos+2:pos+end], "\r"), " ")
+			if line != lastLine {
+				doc = append(doc, text)
+			} else {
+				tokens[len(tokens)-1].trailing = strings.TrimSpace(tokens[len(tokens)-1].trailing + "\n" + text)
+			}
+			pos += end
+			continue
+		case strings.HasPrefix(src[pos:], "/*"):
+			end := strings.Index(src[pos+2:], "*/")
+			if end < 0 {
+				end = len(src) - pos - 4
+			}
+			comment := src[pos : pos+end+4]
+			text := docCommentText("/**" + strings.TrimPrefix(comment[2:], "*"))
+			if line != lastLine {
+				doc = append(doc, text)
+			} else {
+				tokens[len(tokens)-1].trailing = strings.TrimSpace(tokens[len(tokens)-1].trailing + "\n" + text)
+			}
+			line += strings.Count(comment, "\n")
+			pos += end + 4
+			continue
+		case c == '"' || c == '\'':
+			pos++
+			for pos < len(src) && src[pos] != c && src[pos] != '\n' {
+				if src[pos] == '\\' {
+					pos++
+				}
+				pos++
+			}
+			pos++
+		case c == '.' || c == '_' || c == '-' || isIdentByte(c):
+			pos++
+			for pos < len(src) && (isIdentByte(src[pos]) || src[pos] == '.') {
+				pos++
+			}
+		default:
+			pos++
+		}
+		if pos > len(src) {
+			pos = len(src)
+		}
+		tokens = append(tokens, protoToken{srcToken: srcToken{text: src[start:pos], pos: start, end: pos}, doc: strings.Join(doc, "\n")})
+		doc = doc[:0]
+		lastLine = line
+	}
+	return tokens
+}
+
+// protoFile is the parsing state of one .proto file
+type protoFile struct {
+	tokenCursor[protoToken]
+	path      string
+	pkg       string
+	goPackage string
+	imports   []string
+	elements  []CodeElement
+}
+
+// add records an element spanning tokens start to end (exclusive)
+func (f *protoFile) add(el CodeElement, start, end int) int {
+	first, last := f.tok(start), f.tok(end-1)
+	el.File = f.path
+	el.Line = f.line(first.pos)
+	el.EndLine = f.line(last.end - 1)
+	el.Body = f.src[first.pos:last.end]
+	el.Docstring = first.doc
+	el.Exports = true
+	el.Language = "proto"
+	el.IndexedAt = time.Now()
+	f.elements = append(f.elements, el)
+	return len(f.elements) - 1
+}
+
+// unquote strips the quotes of a string literal token
+func unquote(s string) string {
+	if len(s) >= 2 {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// body parses top-level statements, or those of a message body when
+// prefix (the enclosing message names, "Outer.") is set, up to the closing brace.
+// It returns the fields of a message body.
+func (f *protoFile) body(prefix string) []Field {
+	fields := make([]Field, 0)
+	for f.i < len(f.tokens) {
+		start := f.i
+		switch f.peek() {
+		case "}":
+			f.i++
+			if prefix != "" {
+				return fields
+			}
+		case ";":
+			f.i++
+		case "syntax", "edition", "reserved", "extensions":
+			f.skipStatement()
+		case "package":
+			f.i++
+			f.pkg = f.peek()
+			f.skipStatement()
+		case "import":
+			f.i++
+			f.accept("public")
+			f.accept("weak")
+			f.imports = append(f.imports, unquote(f.peek()))
+			f.skipStatement()
+		case "option":
+			f.i++
+			if f.peek() == "go_package" && prefix == "" && f.tok(f.i+1).text == "=" {
+				f.goPackage = strings.SplitN(unquote(f.tok(f.i+2).text), ";", 2)[0]
+			}
+			f.skipStatement()
+		case "message":
+			f.message(prefix)
+		case "enum":
+			f.enum(prefix)
+		case "service":
+			f.service(prefix)
+		case "extend":
+			f.i += 2
+			f.skipBlock()
+		case "oneof":
+			f.i++
+			oneof := f.peek()
+			f.i++
+			f.accept("{")
+			for _, field := range f.body(prefix) {
+				if field.Tags == nil {
+					field.Tags = make(map[string]string)
+				}
+				field.Tags["oneof"] = oneof
+				fields = append(fields, field)
+			}
+		default:
+			if field, ok := f.field(); ok {
+				fields = append(fields, field)
+			}
+			if f.i == start {
+				f.i++
+			}
+		}
+	}
+	return fields
+}
+
+// field parses [repeated|optional|required] Type name = N [options]; and
+// map<K, V> name = N;. The options ([deprecated = true]) become the field's
+// tags, and a comment trailing it joins the one above it.
+func (f *protoFile) field() (Field, bool) {
+	doc := f.tok(f.i).doc
+	typ := f.peek()
+	f.i++
+	switch typ {
+	case "repeated", "optional", "required":
+		typ += " " + f.peek()
+		f.i++
+	case "map":
+		start := f.i
+		for f.i < len(f.tokens) && f.peek() != ">" && f.peek() != ";" {
+			f.i++
+		}
+		f.accept(">")
+		typ = "map" + strings.Join(strings.Fields(f.src[f.tok(start).pos:f.tok(f.i-1).end]), " ")
+	}
+	name := f.peek()
+	f.i++
+	if !f.accept("=") {
+		f.skipStatement()
+		return Field{}, false
+	}
+	number, _ := strconv.Atoi(f.peek())
+	f.i++
+	field := Field{Name: name, Type: typ, Number: number}
+	if f.accept("[") {
+		field.Tags = f.options()
+	}
+	f.skipStatement()
+	if trailing := f.tok(f.i - 1).trailing; trailing != "" {
+		doc = strings.TrimSpace(doc + "\n" + trailing)
+	}
+	field.Docstring = doc
+	return field, true
+}
+
+// options parses the options of a field, after its [, up to the closing ]:
+// deprecated = true, (validate.rules).string.min_len = 1
+func (f *protoFile) options() map[string]string {
+	options := make(map[string]string)
+	for f.i < len(f.tokens) && !f.accept("]") {
+		start := f.i
+		for f.i < len(f.tokens) && f.peek() != "=" && f.peek() != "," && f.peek() != "]" {
+			f.i++
+		}
+		key := strings.Join(strings.Fields(f.src[f.tok(start).pos:f.tok(f.i).pos]), "")
+		value := ""
+		if f.accept("=") {
+			valueStart := f.i
+			for f.i < len(f.tokens) && f.peek() != "," && f.peek() != "]" {
+				if f.peek() == "{" {
+					f.skipBalanced()
+					continue
+				}
+				f.i++
+			}
+			value = strings.TrimSpace(f.src[f.tok(valueStart).pos:f.tok(f.i).pos])
+			if strings.HasPrefix(value, `"`) || strings.HasPrefix(value, "'") {
+				value = unquote(value)
+			}
+		}
+		if key != "" {
+			options[key] = value
+		}
+		f.accept(",")
+	}
+	return options
+}
+
+// message parses a message and the definitions nested in it
+func (f *protoFile) message(prefix string) {
+	start := f.i
+	f.i++ // message
+	name := prefix + f.peek()
+	f.i++
+	f.accept("{")
+	index := f.add(CodeElement{Type: TypeStruct, Name: name}, start, f.i)
+	fields := f.body(name + ".")
+	f.finish(index, start)
+	f.elements[index].Fields = fields
+}
+
+// enum parses an enum and the names of its values
+func (f *protoFile) enum(prefix string) {
+	start := f.i
+	f.i++ // enum
+	name := prefix + f.peek()
+	f.i++
+	f.accept("{")
+	values := make([]string, 0)
+	for f.i < len(f.tokens) && !f.accept("}") {
+		switch f.peek() {
+		case "option", "reserved":
+			f.skipStatement()
+		case ";":
+			f.i++
+		default:
+			values = append(values, f.peek())
+			f.skipStatement()
+		}
+	}
+	f.add(CodeElement{Type: TypeType, Name: name, EnumValues: values}, start, f.i)
+}
+
+// service parses a service and its RPC methods
+func (f *protoFile) service(prefix string) {
+	start := f.i
+	f.i++ // service
+	name := prefix + f.peek()
+	f.i++
+	f.accept("{")
+	index := f.add(CodeElement{Type: TypeInterface, Name: name}, start, f.i)
+
+	methods := make([]string, 0)
+	for f.i < len(f.tokens) && !f.accept("}") {
+		if f.peek() != "rpc" {
+			f.skipStatement()
+			continue
+		}
+		rpcStart := f.i
+		f.i++
+		method := f.peek()
+		f.i++
+		el := CodeElement{Type: TypeFunction, Name: name + "." + method}
+		el.Params = []Parameter{{Name: "request", Type: f.streamType()}}
+		if f.accept("returns") {
+			el.Returns = f.streamType()
+		}
+		f.skipStatement()
+		f.add(el, rpcStart, f.i)
+		methods = append(methods, method)
+	}
+	f.finish(index, start)
+	f.elements[index].Methods = methods
+}
+
+// streamType parses ([stream] Type)
+func (f *protoFile) streamType() string {
+	if !f.accept("(") {
+		return ""
+	}
+	typ := f.peek()
+	f.i++
+	if typ == "stream" && f.peek() != ")" {
+		typ = "stream " + f.peek()
+		f.i++
+	}
+	f.accept(")")
+	return typ
+}
+
+// finish extends the element at index to the token before the current one
+func (f *protoFile) finish(index, start int) {
+	el := &f.elements[index]
+	end := f.tok(f.i - 1)
+	el.Body = f.src[f.tok(start).pos:end.end]
+	el.EndLine = f.line(end.end - 1)
+}
+
+// skipStatement skips to the end of a statement: a semicolon, or a block
+func (f *protoFile) skipStatement() {
+	for f.i < len(f.tokens) {
+		switch f.peek() {
+		case ";":
+			f.i++
+			return
+		case "{":
+			f.skipBlock()
+			return
+		case "}":
+			return
+		case "[", "(":
+			f.skipBalanced()
+			continue
+		}
+		f.i++
+	}
+}
+
+// skipBlock skips to the end of the brace block starting at (or after) the current token
+func (f *protoFile) skipBlock() {
+	for f.i < len(f.tokens) && f.peek() != "{" {
+		f.i++
+	}
+	f.skipBalanced()
+}
+
+// protoGoName returns the name protoc-gen-go gives a message or enum
+// ("Outer.inner_thing" becomes "Outer_InnerThing")
+func protoGoName(name string) string {
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		parts[i] = goCamelCase(part)
+	}
+	return strings.Join(parts, "_")
+}
+
+// goCamelCase converts a proto identifier to a Go one the way protoc-gen-go does
+func goCamelCase(s string) string {
+	isLower := func(c byte) bool { return c >= 'a' && c <= 'z' }
+	b := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '_' && i == 0:
+			b = append(b, 'X')
+		case c == '_' && i+1 < len(s) && isLower(s[i+1]):
+			// The next word starts upper case instead
+		case isDigit(c):
+			b = append(b, c)
+		default:
+			if isLower(c) {
+				c -= 'a' - 'A'
+			}
+			b = append(b, c)
+			for ; i+1 < len(s) && isLower(s[i+1]); i++ {
+				b = append(b, s[i+1])
+			}
+		}
+	}
+	return string(b)
+}
+
+// linkProtos links the Go code generated from .proto files to the
+// definitions: messages and enums to their types, services to their client
+// and server interfaces, RPC methods to the client's methods. The generated
+// Go package is the go_package option or, without one, the one holding
+// name.pb.go for name.proto.
+func linkProtos(elements []CodeElement) {
+	byID := make(map[string]*CodeElement)
+	byProtoFile := make(map[string]*CodeElement) // "api/v1/user|User": generated from user.proto
+	for i := range elements {
+		el := &elements[i]
+		if el.Language != "go" || !strings.HasSuffix(el.File, ".pb.go") {
+			continue
+		}
+		byID[el.ID] = el
+		base := strings.TrimSuffix(strings.TrimSuffix(filepath.Base(el.File), ".pb.go"), "_grpc")
+		local := strings.TrimPrefix(el.ID, el.ImportPath+".")
+		byProtoFile[base+"|"+local] = el
+	}
+	if len(byID) == 0 {
+		return
+	}
+
+	for i := range elements {
+		def := &elements[i]
+		if def.Language != "proto" {
+			continue
+		}
+		lookup := func(local string) *CodeElement {
+			if def.GoPackage != "" {
+				return byID[def.GoPackage+"."+local]
+			}
+			base := strings.TrimSuffix(filepath.Base(def.File), ".proto")
+			return byProtoFile[base+"|"+local]
+		}
+
+		generated := make([]string, 0)
+		switch def.Type {
+		case TypeStruct, TypeType:
+			generated = append(generated, protoGoName(def.Name))
+		case TypeInterface:
+			service := goCamelCase(def.Name)
+			generated = append(generated, service+"Client", service+"Server")
+		case TypeFunction:
+			service, method, _ := strings.Cut(def.Name, ".")
+			client := goCamelCase(service) + "Client"
+			client = strings.ToLower(client[:1]) + client[1:]
+			generated = append(generated, "(*"+client+")."+goCamelCase(method))
+		}
+		for _, local := range generated {
+			if el := lookup(local); el != nil {
+				el.Declaration = def.ID
+				def.ImplementedBy = append(def.ImplementedBy, el.ID)
+			}
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestProtoParserFields(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want Field
+	}{
+		{
+			name: "number",
+			line: "int64 id = 1;",
+			want: Field{Name: "id", Type: "int64", Number: 1},
+		},
+		{
+			name: "trailing comment",
+			line: "// The user's id\n  int64 id = 1; // Never reused",
+			want: Field{Name: "id", Type: "int64", Number: 1, Docstring: "The user's id\nNever reused"},
+		},
+		{
+			name: "options",
+			line: `string name = 2 [deprecated = true, json_name = "fullName"];`,
+			want: Field{Name: "name", Type: "string", Number: 2, Tags: map[string]string{"deprecated": "true", "json_name": "fullName"}},
+		},
+		{
+			name: "oneof with an aggregate option",
+			line: "oneof contact { string email = 4 [(validate.rules).string = {email: true}]; }",
+			want: Field{Name: "email", Type: "string", Number: 4, Tags: map[string]string{"(validate.rules).string": "{email: true}", "oneof": "contact"}},
+		},
+		{
+			name: "map",
+			line: "map<string, int32> counts = 3; /* per kind */",
+			want: Field{Name: "counts", Type: "map<string, int32>", Number: 3, Docstring: "per kind"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := "syntax = \"proto3\";\npackage demo;\n\nmessage User {\n  " + tt.line + "\n}\n"
+			result, err := NewProtoParser().Parse("user.proto", []byte(src))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(result.Elements) != 1 || len(result.Elements[0].Fields) != 1 {
+				t.Fatalf("elements %+v, want one message with one field", result.Elements)
+			}
+			if got := result.Elements[0].Fields[0]; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("field %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
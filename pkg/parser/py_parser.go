@@ -0,0 +1,749 @@
+package parser
+
+import (
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// PythonParser parses Python source. It works on logical lines and their
+// indentation, reading def and class headers and skipping the statements
+// in between, so any Python 3 version parses.
+type PythonParser struct{}
+
+// NewPythonParser creates a new Python parser
+func NewPythonParser() *PythonParser {
+	return &PythonParser{}
+}
+
+// SupportsFile checks if the parser supports this file
+func (p *PythonParser) SupportsFile(filePath string) bool {
+	ext := filepath.Ext(filePath)
+	return ext == ".py" || ext == ".pyi"
+}
+
+// Parse parses Python source and extracts the module, its classes and functions
+func (p *PythonParser) Parse(filePath string, content []byte) (*ParseResult, error) {
+	src := string(content)
+	f := &pyFile{
+		src:    src,
+		lines:  pyLogicalLines(src),
+		starts: lineStarts(src),
+		path:   filePath,
+		module: pyModuleName(filePath),
+	}
+
+	elements := f.suite(0, len(f.lines), "", false)
+
+	// __all__ decides what a star import exports; otherwise underscore names are private
+	for i := range elements {
+		el := &elements[i]
+		top := !strings.Contains(el.Name, ".")
+		if f.all != nil && top {
+			el.Exports = f.all[el.Name]
+		}
+		el.Imports = f.imports
+		el.Hash = HashCode(el.ID + "\n" + el.Body)
+	}
+
+	module := f.moduleElement(elements)
+	return &ParseResult{
+		Elements: append([]CodeElement{module}, elements...),
+		Errors:   make([]ParseError, 0),
+	}, nil
+}
+
+// pyModuleName derives the dotted module name from a file path:
+// "pipeline/etl/load.py" is pipeline.etl.load, "pipeline/__init__.py" pipeline
+func pyModuleName(filePath string) string {
+	name := strings.TrimSuffix(filepath.ToSlash(filePath), filepath.Ext(filePath))
+	name = strings.TrimSuffix(strings.TrimSuffix(name, "__init__"), "/")
+	if name == "" {
+		name = filepath.Base(filepath.Dir(filePath))
+	}
+	return strings.ReplaceAll(name, "/", ".")
+}
+
+// pyToken is a token of a logical line with its byte range
+type pyToken struct {
+	text   string
+	pos    int
+	end    int
+	string bool // A string literal (text includes prefix and quotes)
+}
+
+// pyLine is a logical line: physical lines joined by brackets or backslashes
+type pyLine struct {
+	indent int
+	tokens []pyToken
+}
+
+// pyFile is the parsing state of one Python file
+type pyFile struct {
+	src     string
+	lines   []pyLine
+	starts  lineIndex
+	path    string
+	module  string
+	imports []string
+	all     map[string]bool // Names listed in __all__
+	doc     string
+}
+
+// pyLogicalLines splits Python source into logical lines of tokens,
+// dropping comments and blank lines
+func pyLogicalLines(src string) []pyLine {
+	lines := make([]pyLine, 0)
+	var current *pyLine
+	depth := 0
+	lineStart := 0
+
+	for pos := 0; pos < len(src); {
+		c := src[pos]
+		switch {
+		case c == '\n':
+			if depth == 0 {
+				current = nil
+			}
+			pos++
+			lineStart = pos
+			continue
+		case c == '\\' && pos+1 < len(src) && (src[pos+1] == '\n' || src[pos+1] == '\r'):
+			pos = strings.IndexByte(src[pos:], '\n') + pos + 1
+			continue // Explicit line joining
+		case c == ' ' || c == '\t' || c == '\r' || c == '\f':
+			pos++
+			continue
+		case c == '#':
+			end := strings.IndexByte(src[pos:], '\n')
+			if end < 0 {
+				end = len(src) - pos
+			}
+			pos += end
+			continue
+		}
+
+		if current == nil {
+			lines = append(lines, pyLine{indent: pyIndent(src[lineStart:pos])})
+			current = &lines[len(lines)-1]
+		}
+
+		start := pos
+		isString := false
+		switch {
+		case c == '"' || c == '\'':
+			pos = pySkipString(src, pos)
+			isString = true
+		case c >= '0' && c <= '9' || c == '.' && pos+1 < len(src) && isDigit(src[pos+1]):
+			for pos < len(src) && (isIdentByte(src[pos]) || src[pos] == '.') {
+				pos++
+			}
+		case c == '_' || c >= 0x80 || unicode.IsLetter(rune(c)):
+			for pos < len(src) {
+				r, size := utf8.DecodeRuneInString(src[pos:])
+				if r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+					break
+				}
+				pos += size
+			}
+			// String prefixes: r"", b'', f"""...""", rb''
+			if pos < len(src) && (src[pos] == '"' || src[pos] == '\'') && pos-start <= 2 &&
+				strings.Trim(strings.ToLower(src[start:pos]), "rbuf") == "" {
+				pos = pySkipString(src, pos)
+				isString = true
+			}
+		case strings.HasPrefix(src[pos:], "->") || strings.HasPrefix(src[pos:], "**") ||
+			strings.HasPrefix(src[pos:], ":="):
+			pos += 2
+		default:
+			switch c {
+			case '(', '[', '{':
+				depth++
+			case ')', ']', '}':
+				if depth > 0 {
+					depth--
+				}
+			}
+			pos++
+		}
+		current.tokens = append(current.tokens, pyToken{text: src[start:pos], pos: start, end: pos, string: isString})
+	}
+	return lines
+}
+
+// pyIndent measures indentation, with tabs to the next multiple of eight
+func pyIndent(prefix string) int {
+	indent := 0
+	for _, c := range prefix {
+		if c == '\t' {
+			indent += 8 - indent%8
+		} else {
+			indent++
+		}
+	}
+	return indent
+}
+
+// pySkipString returns the offset after the string literal starting at pos
+func pySkipString(src string, pos int) int {
+	quote := src[pos : pos+1]
+	if strings.HasPrefix(src[pos:], strings.Repeat(quote, 3)) {
+		quote = strings.Repeat(quote, 3)
+	}
+	for i := pos + len(quote); i < len(src); i++ {
+		switch {
+		case src[i] == '\\':
+			i++
+		case strings.HasPrefix(src[i:], quote):
+			return i + len(quote)
+		case src[i] == '\n' && len(quote) == 1:
+			return i // Unterminated
+		}
+	}
+	return len(src)
+}
+
+// blockEnd returns the index of the first line after the block opened by line i
+func (f *pyFile) blockEnd(i, end int) int {
+	j := i + 1
+	for j < end && f.lines[j].indent > f.lines[i].indent {
+		j++
+	}
+	return j
+}
+
+// suite extracts the definitions of lines start to end. prefix qualifies
+// the names ("Class."); in a class, functions are methods.
+func (f *pyFile) suite(start, end int, prefix string, inClass bool) []CodeElement {
+	elements := make([]CodeElement, 0)
+	assigned := make(map[string]bool)
+	for i := start; i < end; i++ {
+		// A string as the first statement documents the module
+		if line := f.lines[i]; i == 0 && line.tokens[0].string && len(line.tokens) == 1 {
+			f.doc = pyDocText(line.tokens[0].text)
+			continue
+		}
+
+		defLine := i
+		decorators := make([]string, 0)
+		for defLine < end && f.lines[defLine].tokens[0].text == "@" && len(f.lines[defLine].tokens) > 1 {
+			d := f.lines[defLine]
+			decorators = append(decorators, f.src[d.tokens[1].pos:d.tokens[len(d.tokens)-1].end])
+			defLine++
+		}
+		if defLine == end {
+			break
+		}
+
+		def := f.lines[defLine]
+		keyword := def.tokens[0].text
+		if keyword == "async" && len(def.tokens) > 1 {
+			keyword = def.tokens[1].text
+		}
+		switch keyword {
+		case "def", "class":
+			blockEnd := f.blockEnd(defLine, end)
+			el := f.definition(i, defLine, blockEnd, prefix, inClass, decorators)
+			i = blockEnd - 1
+			if el.Name == "" {
+				continue
+			}
+			if el.Type != TypeClass {
+				elements = append(elements, el)
+				continue
+			}
+
+			members := f.suite(defLine+1, blockEnd, el.Name+".", true)
+			for _, m := range members {
+				method := strings.TrimPrefix(m.Name, el.Name+".")
+				if m.Type == TypeFunction && !strings.Contains(method, ".") {
+					el.Methods = append(el.Methods, method)
+				}
+			}
+			el.Fields = f.classFields(defLine+1, blockEnd)
+			elements = append(elements, el)
+			elements = append(elements, members...)
+
+		case "import", "from":
+			if prefix == "" {
+				f.importLine(def)
+			}
+			i = defLine
+
+		case "if", "elif", "else", "try", "except", "finally", "with":
+			// Conditional definitions (try: import ... except ImportError: def ...)
+			blockEnd := f.blockEnd(defLine, end)
+			elements = append(elements, f.suite(defLine+1, blockEnd, prefix, inClass)...)
+			i = blockEnd - 1
+
+		default:
+			if prefix == "" && !inClass {
+				if el, ok := f.assignment(defLine); ok && !assigned[el.Name] {
+					assigned[el.Name] = true
+					elements = append(elements, el)
+				}
+			}
+			i = f.blockEnd(defLine, end) - 1
+		}
+	}
+	return elements
+}
+
+// definition builds the element of a def or class whose decorators start at
+// line start, header is at line def and block ends before line end
+func (f *pyFile) definition(start, def, end int, prefix string, inClass bool, decorators []string) CodeElement {
+	tokens := f.lines[def].tokens
+	el := CodeElement{Decorators: decorators}
+	if len(decorators) == 0 {
+		el.Decorators = nil
+	}
+
+	t := 0
+	if tokens[t].text == "async" {
+		el.Async = true
+		t++
+	}
+	keyword := tokens[t].text
+	t++
+	if t >= len(tokens) {
+		return CodeElement{}
+	}
+	name := tokens[t].text
+	t++
+	el.Name = prefix + name
+	el.ID = f.module + "." + el.Name
+	el.Exports = !strings.HasPrefix(name, "_") || strings.HasPrefix(name, "__") && strings.HasSuffix(name, "__")
+
+	// PEP 695 type parameters: def f[T](x: T)
+	if t < len(tokens) && tokens[t].text == "[" {
+		rbrack := pyMatching(tokens, t)
+		if rbrack < len(tokens) {
+			el.TypeParams = f.params(tokens[t+1 : rbrack])
+		}
+		t = rbrack + 1
+	}
+
+	// The header ends at the colon opening the block
+	colon := len(tokens)
+	depth := 0
+	for k := t; k < len(tokens); k++ {
+		switch tokens[k].text {
+		case "(", "[", "{":
+			depth++
+		case ")", "]", "}":
+			depth--
+		case ":":
+			if depth == 0 && colon == len(tokens) {
+				colon = k
+			}
+		}
+	}
+
+	switch keyword {
+	case "class":
+		el.Type = TypeClass
+		el.Methods = []string{}
+		if rparen := pyMatching(tokens, t); t < colon && tokens[t].text == "(" && rparen < len(tokens) {
+			bases := make([]string, 0)
+			for _, arg := range pySplitArgs(tokens[t+1 : rparen]) {
+				// metaclass= and other class keywords are not bases
+				if len(arg) > 1 && arg[1].text == "=" {
+					continue
+				}
+				bases = append(bases, f.tokenText(arg))
+			}
+			el.Extends = strings.Join(bases, ", ")
+		}
+	default:
+		el.Type = TypeFunction
+		if rparen := pyMatching(tokens, t); t < colon && tokens[t].text == "(" && rparen < len(tokens) {
+			params := f.params(tokens[t+1 : rparen])
+			// The instance or class a method is bound to is not an argument
+			if inClass && len(params) > 0 && !hasDecorator(decorators, "staticmethod") {
+				params = params[1:]
+			}
+			el.Params = params
+			if rparen+1 < colon && tokens[rparen+1].text == "->" {
+				el.Returns = f.tokenText(tokens[rparen+2 : colon])
+			}
+		}
+		el.Generator = f.yields(def, end)
+	}
+
+	first := f.lines[start].tokens[0]
+	last := f.lines[end-1].tokens[len(f.lines[end-1].tokens)-1]
+	el.File = f.path
+	el.Line = f.starts.line(first.pos)
+	el.EndLine = f.starts.line(last.end - 1)
+	el.ImportPath = f.module
+	el.Body = f.src[first.pos:last.end]
+	el.Language = "python"
+	el.IndexedAt = time.Now()
+
+	// The docstring is the string the block starts with
+	if colon+1 < len(tokens) && tokens[colon+1].string {
+		el.Docstring = pyDocText(tokens[colon+1].text)
+	} else if def+1 < end && f.lines[def+1].tokens[0].string {
+		el.Docstring = pyDocText(f.lines[def+1].tokens[0].text)
+	}
+	return el
+}
+
+// hasDecorator reports whether decorators include name
+func hasDecorator(decorators []string, name string) bool {
+	for _, d := range decorators {
+		if d == name {
+			return true
+		}
+	}
+	return false
+}
+
+// yields reports whether the function defined at line def (block ending at
+// end) is a generator: it yields outside of any nested function or class
+func (f *pyFile) yields(def, end int) bool {
+	for i := def; i < end; i++ {
+		tokens := f.lines[i].tokens
+		if i > def && (tokens[0].text == "def" || tokens[0].text == "class" ||
+			tokens[0].text == "async" && len(tokens) > 1 && tokens[1].text == "def") {
+			i = f.blockEnd(i, end) - 1
+			continue
+		}
+		for k, tok := range tokens {
+			if tok.text == "yield" && (i > def || k > 0) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// params parses parameters ("a: int = 1, *args, b, **kwargs"); the bare
+// "*" and "/" markers are dropped
+func (f *pyFile) params(tokens []pyToken) []Parameter {
+	params := make([]Parameter, 0)
+	for _, arg := range pySplitArgs(tokens) {
+		if len(arg) == 0 || len(arg) == 1 && (arg[0].text == "*" || arg[0].text == "/") {
+			continue
+		}
+		param := Parameter{}
+		k := 0
+		if arg[0].text == "*" || arg[0].text == "**" {
+			param.Name = arg[0].text
+			k++
+		}
+		if k < len(arg) {
+			param.Name += arg[k].text
+			k++
+		}
+
+		depth := 0
+		typeStart, defaultStart := -1, -1
+		for j := k; j < len(arg); j++ {
+			switch arg[j].text {
+			case "(", "[", "{":
+				depth++
+			case ")", "]", "}":
+				depth--
+			case ":":
+				if depth == 0 && typeStart < 0 && defaultStart < 0 {
+					typeStart = j + 1
+				}
+			case "=":
+				if depth == 0 && defaultStart < 0 {
+					defaultStart = j + 1
+				}
+			}
+		}
+		if typeStart >= 0 {
+			typeEnd := len(arg)
+			if defaultStart >= 0 {
+				typeEnd = defaultStart - 1
+			}
+			param.Type = f.tokenText(arg[typeStart:typeEnd])
+		}
+		if defaultStart >= 0 {
+			param.Default = f.tokenText(arg[defaultStart:])
+			param.Optional = true
+		}
+		params = append(params, param)
+	}
+	return params
+}
+
+// classFields lists the attributes assigned or annotated in a class body
+func (f *pyFile) classFields(start, end int) []Field {
+	fields := make([]Field, 0)
+	seen := make(map[string]bool)
+	indent := -1
+	for i := start; i < end; i++ {
+		tokens := f.lines[i].tokens
+		if indent < 0 {
+			indent = f.lines[i].indent
+		}
+		if f.lines[i].indent != indent || len(tokens) < 2 || seen[tokens[0].text] {
+			continue
+		}
+		name := tokens[0].text
+		if !isPyName(name) {
+			continue
+		}
+		switch tokens[1].text {
+		case ":":
+			field := Field{Name: name}
+			typeEnd := len(tokens)
+			for j := 2; j < len(tokens); j++ {
+				if tokens[j].text == "=" {
+					typeEnd = j
+					break
+				}
+			}
+			field.Type = f.tokenText(tokens[2:typeEnd])
+			fields = append(fields, field)
+			seen[name] = true
+		case "=":
+			fields = append(fields, Field{Name: name})
+			seen[name] = true
+		}
+	}
+	return fields
+}
+
+// assignment builds a constant or variable element from a module-level
+// assignment (NAME = value or NAME: type = value); lambdas become functions
+func (f *pyFile) assignment(i int) (CodeElement, bool) {
+	tokens := f.lines[i].tokens
+	if len(tokens) < 3 || !isPyName(tokens[0].text) || pyKeywords[tokens[0].text] {
+		return CodeElement{}, false
+	}
+	name := tokens[0].text
+
+	el := CodeElement{Type: TypeVariable, Name: name}
+	eq := 1
+	if tokens[1].text == ":" {
+		eq = len(tokens)
+		for j := 2; j < len(tokens); j++ {
+			if tokens[j].text == "=" {
+				eq = j
+				break
+			}
+		}
+		el.ValueType = f.tokenText(tokens[2:eq])
+	} else if tokens[1].text != "=" {
+		return CodeElement{}, false
+	}
+
+	if name == "__all__" && eq+1 < len(tokens) {
+		f.all = make(map[string]bool)
+		for _, tok := range tokens[eq+1:] {
+			if tok.string {
+				f.all[pyDocText(tok.text)] = true
+			}
+		}
+	}
+
+	if eq+1 < len(tokens) {
+		value := tokens[eq+1:]
+		if value[0].text == "lambda" {
+			el.Type = TypeFunction
+			colon := len(value)
+			for j, tok := range value {
+				if tok.text == ":" {
+					colon = j
+					break
+				}
+			}
+			el.Params = f.params(value[1:colon])
+		} else if text := f.tokenText(value); !strings.Contains(text, "\n") {
+			el.Value = text
+		}
+	}
+	if el.Type == TypeVariable && strings.ToUpper(name) == name && strings.ContainsAny(name, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") {
+		el.Type = TypeConstant // By convention
+	}
+
+	first, last := tokens[0], tokens[len(tokens)-1]
+	el.ID = f.module + "." + name
+	el.File = f.path
+	el.Line = f.starts.line(first.pos)
+	el.EndLine = f.starts.line(last.end - 1)
+	el.ImportPath = f.module
+	el.Body = f.src[first.pos:last.end]
+	el.Exports = !strings.HasPrefix(name, "_")
+	el.Language = "python"
+	el.IndexedAt = time.Now()
+
+	// A string right after an assignment documents it (attribute docstrings)
+	if i+1 < len(f.lines) && f.lines[i+1].indent == f.lines[i].indent &&
+		len(f.lines[i+1].tokens) == 1 && f.lines[i+1].tokens[0].string {
+		el.Docstring = pyDocText(f.lines[i+1].tokens[0].text)
+	}
+	return el, true
+}
+
+// pyKeywords are the statement keywords a logical line can start with
+var pyKeywords = map[string]bool{
+	"if": true, "elif": true, "else": true, "for": true, "while": true, "try": true,
+	"except": true, "finally": true, "with": true, "return": true, "pass": true,
+	"raise": true, "del": true, "global": true, "nonlocal": true, "assert": true,
+	"break": true, "continue": true, "lambda": true, "yield": true, "await": true,
+}
+
+// importLine records the modules of an import or from-import statement
+func (f *pyFile) importLine(line pyLine) {
+	tokens := line.tokens
+	if tokens[0].text == "from" {
+		module := ""
+		for k := 1; k < len(tokens) && tokens[k].text != "import"; k++ {
+			module += tokens[k].text
+		}
+		f.imports = append(f.imports, module)
+		return
+	}
+
+	// import a.b as c, d
+	module := ""
+	for k := 1; k <= len(tokens); k++ {
+		if k == len(tokens) || tokens[k].text == "," {
+			if module != "" {
+				f.imports = append(f.imports, module)
+			}
+			module = ""
+			continue
+		}
+		if tokens[k].text == "as" {
+			k++ // Skip the alias
+			continue
+		}
+		module += tokens[k].text
+	}
+}
+
+// moduleElement summarises the file as a module element
+func (f *pyFile) moduleElement(elements []CodeElement) CodeElement {
+	exported := 0
+	for _, el := range elements {
+		if el.Exports && !strings.Contains(el.Name, ".") {
+			exported++
+		}
+	}
+
+	name := f.module
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+	body := ""
+	endLine := 1
+	if len(f.lines) > 0 && f.doc != "" {
+		tok := f.lines[0].tokens[0]
+		body = tok.text
+		endLine = f.starts.line(tok.end - 1)
+	}
+
+	return CodeElement{
+		Type:            TypePackage,
+		Name:            name,
+		ID:              f.module,
+		File:            f.path,
+		Line:            1,
+		EndLine:         endLine,
+		Hash:            HashCode(f.module + "\n" + body),
+		Body:            body,
+		Docstring:       f.doc,
+		ImportPath:      f.module,
+		Files:           []string{f.path},
+		ExportedSymbols: exported,
+		Imports:         f.imports,
+		Language:        "python",
+		IndexedAt:       time.Now(),
+	}
+}
+
+// pyMatching returns the index of the bracket closing the one at open, or
+// len(tokens) when the file ends before it is closed
+func pyMatching(tokens []pyToken, open int) int {
+	depth := 0
+	for i := open; i < len(tokens); i++ {
+		switch tokens[i].text {
+		case "(", "[", "{":
+			depth++
+		case ")", "]", "}":
+			if depth--; depth == 0 {
+				return i
+			}
+		}
+	}
+	return len(tokens)
+}
+
+// pySplitArgs splits tokens at the commas outside brackets
+func pySplitArgs(tokens []pyToken) [][]pyToken {
+	args := make([][]pyToken, 0)
+	depth := 0
+	start := 0
+	for i, tok := range tokens {
+		switch tok.text {
+		case "(", "[", "{":
+			depth++
+		case ")", "]", "}":
+			depth--
+		case ",":
+			if depth == 0 {
+				args = append(args, tokens[start:i])
+				start = i + 1
+			}
+		}
+	}
+	if start < len(tokens) {
+		args = append(args, tokens[start:])
+	}
+	return args
+}
+
+// tokenText returns the source spanned by tokens
+func (f *pyFile) tokenText(tokens []pyToken) string {
+	if len(tokens) == 0 {
+		return ""
+	}
+	return strings.TrimSpace(f.src[tokens[0].pos:tokens[len(tokens)-1].end])
+}
+
+// isPyName reports whether s is an identifier
+func isPyName(s string) bool {
+	r, _ := utf8.DecodeRuneInString(s)
+	return r == '_' || unicode.IsLetter(r)
+}
+
+// pyDocText strips the prefix and quotes from a string literal and removes
+// the indentation its continuation lines share, like inspect.cleandoc
+func pyDocText(literal string) string {
+	literal = strings.TrimLeft(literal, "rRbBuUfF")
+	for _, quote := range []string{`"""`, `'''`, `"`, `'`} {
+		if strings.HasPrefix(literal, quote) && strings.HasSuffix(literal, quote) && len(literal) >= 2*len(quote) {
+			literal = literal[len(quote) : len(literal)-len(quote)]
+			break
+		}
+	}
+
+	lines := strings.Split(literal, "\n")
+	indent := -1
+	for _, line := range lines[1:] {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if n := len(line) - len(strings.TrimLeft(line, " \t")); indent < 0 || n < indent {
+			indent = n
+		}
+	}
+	for i := 1; i < len(lines); i++ {
+		if len(lines[i]) >= indent && indent > 0 {
+			lines[i] = lines[i][indent:]
+		} else {
+			lines[i] = strings.TrimSpace(lines[i])
+		}
+	}
+	lines[0] = strings.TrimSpace(lines[0])
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
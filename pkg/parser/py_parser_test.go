@@ -0,0 +1,35 @@
+package parser
+
+import "testing"
+
+func TestPythonParserUnclosedBracket(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		element string
+		extends string
+	}{
+		{name: "class bases", src: "class A(", element: "A"},
+		{name: "def params", src: "def f(\n", element: "f"},
+		{name: "def params and body", src: "def f(a, b\n    return a\n", element: "f"},
+		{name: "type params", src: "def g[T(x: T):\n    pass\n", element: "g"},
+		{name: "closed bases", src: "class B(Base):\n    pass\n", element: "B", extends: "Base"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := NewPythonParser().Parse("pkg/mod.py", []byte(tt.src))
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			for _, el := range result.Elements {
+				if el.Name == tt.element {
+					if el.Extends != tt.extends {
+						t.Errorf("Extends = %q, want %q", el.Extends, tt.extends)
+					}
+					return
+				}
+			}
+			t.Errorf("no element %q in %d elements", tt.element, len(result.Elements))
+		})
+	}
+}
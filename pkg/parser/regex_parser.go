@@ -0,0 +1,336 @@
+package parser
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Profile describes a language to the RegexParser: how its files are
+// recognized and which lines declare functions and classes. The name of a
+// declaration is the group named "name" (else the first group) of its
+// pattern, its parameters the group named "params"; alternatives may
+// repeat both names.
+type Profile struct {
+	Language   string   `json:"language"`
+	Extensions []string `json:"extensions,omitempty"`
+	Filenames  []string `json:"filenames,omitempty"`
+	Shebangs   []string `json:"shebangs,omitempty"`
+	Function   string   `json:"function"`
+	Class      string   `json:"class,omitempty"`
+	Comment    string   `json:"comment,omitempty"` // Line comment prefix ("#", "--")
+	Blocks     string   `json:"blocks,omitempty"`  // How a declaration ends: "indent" (the default) or "braces"
+}
+
+// DefaultProfiles returns the profiles of the scripting languages without a dedicated parser
+func DefaultProfiles() []Profile {
+	return []Profile{
+		{
+			Language:   "ruby",
+			Extensions: []string{".rb", ".rake"},
+			Filenames:  []string{"Rakefile", "Gemfile"},
+			Shebangs:   []string{"ruby"},
+			Function:   `^\s*def\s+(?P<name>(?:self\.)?[\w.]+[?!=]?)\s*(?:\(?(?P<params>[^)#]*)\)?)?`,
+			Class:      `^\s*(?:class|module)\s+(?P<name>[A-Z][\w:]*)`,
+			Comment:    "#",
+			Blocks:     "indent",
+		},
+		{
+			Language:   "lua",
+			Extensions: []string{".lua"},
+			Shebangs:   []string{"lua", "luajit"},
+			Function:   `^\s*(?:local\s+)?(?:function\s+(?P<name>[\w.:]+)|(?P<name>[\w.]+)\s*=\s*function)\s*\((?P<params>[^)]*)\)`,
+			Comment:    "--",
+			Blocks:     "indent",
+		},
+		{
+			Language:   "perl",
+			Extensions: []string{".pl", ".pm"},
+			Shebangs:   []string{"perl"},
+			Function:   `^\s*sub\s+(?P<name>[\w:]+)`,
+			Class:      `^\s*package\s+(?P<name>[\w:]+)`,
+			Comment:    "#",
+			Blocks:     "braces",
+		},
+	}
+}
+
+// RegexParser is the fallback parser for languages without a dedicated
+// one. Declarations are found line by line with the patterns of a Profile;
+// where they end is approximated by indentation or brace matching.
+type RegexParser struct {
+	profile  Profile
+	function *regexp.Regexp
+	class    *regexp.Regexp
+}
+
+// NewRegexParser creates a parser for the language of profile
+func NewRegexParser(profile Profile) (*RegexParser, error) {
+	if profile.Function == "" && profile.Class == "" {
+		return nil, fmt.Errorf("profile %s: no function or class pattern", profile.Language)
+	}
+	if profile.Blocks != "" && profile.Blocks != "indent" && profile.Blocks != "braces" {
+		return nil, fmt.Errorf("profile %s: unknown blocks %q", profile.Language, profile.Blocks)
+	}
+
+	p := &RegexParser{profile: profile}
+	var err error
+	if profile.Function != "" {
+		if p.function, err = regexp.Compile(profile.Function); err != nil {
+			return nil, fmt.Errorf("profile %s: function pattern: %w", profile.Language, err)
+		}
+	}
+	if profile.Class != "" {
+		if p.class, err = regexp.Compile(profile.Class); err != nil {
+			return nil, fmt.Errorf("profile %s: class pattern: %w", profile.Language, err)
+		}
+	}
+	return p, nil
+}
+
+// SupportsFile checks if the parser supports this file
+func (p *RegexParser) SupportsFile(filePath string) bool {
+	base := filepath.Base(filePath)
+	for _, name := range p.profile.Filenames {
+		if name == base {
+			return true
+		}
+	}
+	ext := filepath.Ext(base)
+	for _, e := range p.profile.Extensions {
+		if e == ext {
+			return true
+		}
+	}
+	return false
+}
+
+// Parse extracts the functions and classes declared in content
+func (p *RegexParser) Parse(filePath string, content []byte) (*ParseResult, error) {
+	lines := strings.Split(strings.ReplaceAll(string(content), "\r\n", "\n"), "\n")
+	module := strings.TrimSuffix(filepath.ToSlash(filePath), filepath.Ext(filePath))
+
+	elements := make([]CodeElement, 0)
+	for i, line := range lines {
+		if p.class != nil {
+			if m := p.class.FindStringSubmatch(line); m != nil {
+				elements = append(elements, p.element(TypeClass, p.class, m, lines, i, filePath, module))
+				continue
+			}
+		}
+		if p.function != nil {
+			if m := p.function.FindStringSubmatch(line); m != nil {
+				elements = append(elements, p.element(TypeFunction, p.function, m, lines, i, filePath, module))
+			}
+		}
+	}
+
+	// A class declared without a block ("package Foo;") lasts until the next one
+	var class *CodeElement
+	for i := range elements {
+		el := &elements[i]
+		if el.Type != TypeClass {
+			continue
+		}
+		if class != nil && class.EndLine == class.Line {
+			class.EndLine = el.Line - 1
+			class.Body = strings.Join(lines[class.Line-1:class.EndLine], "\n")
+		}
+		class = el
+	}
+	if class != nil && class.EndLine == class.Line {
+		class.EndLine = len(lines)
+		class.Body = strings.Join(lines[class.Line-1:], "\n")
+	}
+
+	// Declarations within a class are qualified by it; functions are its methods.
+	// Elements are in line order, so enclosing classes are qualified first.
+	for i := range elements {
+		el := &elements[i]
+		var owner *CodeElement
+		for j := range elements[:i] {
+			c := &elements[j]
+			if c.Type == TypeClass && c.Line < el.Line && c.EndLine >= el.EndLine &&
+				(owner == nil || c.Line > owner.Line) {
+				owner = c
+			}
+		}
+		if owner == nil {
+			continue
+		}
+		if el.Type == TypeFunction {
+			owner.Methods = append(owner.Methods, el.Name)
+		}
+		el.Name = owner.Name + "." + el.Name
+	}
+
+	for i := range elements {
+		el := &elements[i]
+		el.ID = module + "." + el.Name
+		el.Hash = HashCode(el.ID + "\n" + el.Body)
+	}
+	return &ParseResult{
+		Elements: elements,
+		Errors:   make([]ParseError, 0),
+	}, nil
+}
+
+// element builds the element declared by match m on line i
+func (p *RegexParser) element(typ ElementType, re *regexp.Regexp, m []string, lines []string, i int, filePath, module string) CodeElement {
+	name, params := "", ""
+	for g, group := range re.SubexpNames() {
+		switch {
+		case group == "name" && name == "":
+			name = m[g]
+		case group == "params" && params == "":
+			params = m[g]
+		}
+	}
+	if name == "" && len(m) > 1 {
+		name = m[1]
+	}
+
+	end := p.blockEnd(lines, i)
+	el := CodeElement{
+		Type:       typ,
+		Name:       name,
+		File:       filePath,
+		Line:       i + 1,
+		EndLine:    end + 1,
+		ImportPath: module,
+		Body:       strings.Join(lines[i:end+1], "\n"),
+		Docstring:  p.commentAbove(lines, i),
+		Language:   p.profile.Language,
+		IndexedAt:  time.Now(),
+	}
+	if typ == TypeFunction {
+		el.Params = make([]Parameter, 0)
+		for _, param := range strings.Split(params, ",") {
+			if param = strings.TrimSpace(param); param != "" {
+				el.Params = append(el.Params, Parameter{Name: param})
+			}
+		}
+	}
+	return el
+}
+
+// blockEnd returns the last line of the declaration starting on line start
+func (p *RegexParser) blockEnd(lines []string, start int) int {
+	if p.profile.Blocks == "braces" {
+		return p.braceEnd(lines, start)
+	}
+
+	// The block is the lines indented deeper, and a closing line ("end", "}") at the same depth
+	indent := len(lines[start]) - len(strings.TrimLeft(lines[start], " \t"))
+	last := start
+	for i := start + 1; i < len(lines); i++ {
+		text := strings.TrimSpace(lines[i])
+		if text == "" {
+			continue
+		}
+		depth := len(lines[i]) - len(strings.TrimLeft(lines[i], " \t"))
+		if depth > indent {
+			last = i
+			continue
+		}
+		if depth == indent && (strings.HasPrefix(text, "end") || strings.HasPrefix(text, "}")) {
+			last = i
+		}
+		break
+	}
+	return last
+}
+
+// braceEnd returns the line of the brace closing the block opened on the
+// declaration line (or the line after it), skipping quoted text and
+// comments. A declaration without a block ends on its own line.
+func (p *RegexParser) braceEnd(lines []string, start int) int {
+	depth := 0
+	opened := false
+	for i := start; i < len(lines); i++ {
+		line := lines[i]
+		if p.profile.Comment != "" {
+			line = stripLineComment(line, p.profile.Comment)
+		}
+		var quote byte
+		for j := 0; j < len(line); j++ {
+			c := line[j]
+			switch {
+			case quote != 0:
+				if c == '\\' {
+					j++
+				} else if c == quote {
+					quote = 0
+				}
+			case c == '"' || c == '\'':
+				quote = c
+			case c == '{':
+				depth++
+				opened = true
+			case c == '}':
+				depth--
+				if opened && depth == 0 {
+					return i
+				}
+			case c == ';' && !opened:
+				return start // A forward declaration or statement
+			}
+		}
+		if !opened && i > start { // The block opens on the declaration line or the next
+			return start
+		}
+	}
+	if opened {
+		return len(lines) - 1
+	}
+	return start
+}
+
+// stripLineComment cuts a line at its comment, outside quotes. The prefix
+// only starts a comment at the start of the line or after whitespace, so
+// Perl's $#array and shell's $# are code.
+func stripLineComment(line, prefix string) string {
+	var quote byte
+	for j := 0; j < len(line); j++ {
+		c := line[j]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				j++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case strings.HasPrefix(line[j:], prefix) && (j == 0 || line[j-1] == ' ' || line[j-1] == '\t'):
+			return line[:j]
+		}
+	}
+	return line
+}
+
+// commentAbove returns the line comments directly above line i, without their prefix
+func (p *RegexParser) commentAbove(lines []string, i int) string {
+	if p.profile.Comment == "" {
+		return ""
+	}
+	return lineCommentAbove(lines, i, p.profile.Comment)
+}
+
+// lineCommentAbove returns the comment lines starting with prefix directly
+// above line i, without the prefix; a shebang line is not a comment
+func lineCommentAbove(lines []string, i int, prefix string) string {
+	start := i
+	for start > 0 && strings.HasPrefix(strings.TrimSpace(lines[start-1]), prefix) &&
+		!strings.HasPrefix(lines[start-1], "#!") {
+		start--
+	}
+	comment := make([]string, 0, i-start)
+	for _, line := range lines[start:i] {
+		line = strings.TrimLeft(strings.TrimSpace(line), prefix[:1])
+		comment = append(comment, strings.TrimPrefix(line, " "))
+	}
+	return strings.TrimSpace(strings.Join(comment, "\n"))
+}
@@ -0,0 +1,204 @@
+package parser
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Language describes how the files of a language are recognized and
+// which parser reads them
+type Language struct {
+	Name       string
+	Extensions []string // ".py"
+	Filenames  []string // Exact base names ("Makefile")
+	Shebangs   []string // Interpreters of extensionless scripts ("python"), versions stripped
+	Parser     Parser
+}
+
+// Registry dispatches files to the parser of their language, by
+// extension, file name or shebang line. All registered languages are
+// active until SetLanguages narrows them down.
+type Registry struct {
+	languages []*Language
+	active    map[string]bool // nil: all active
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// DefaultRegistry returns a registry of every language with a parser. Go
+// is registered for recognition only: it is loaded a package at a time by
+// the GoLoader, together with the assembly and cgo sources of the package;
+// .s and .c files outside a Go package directory are not indexed.
+func DefaultRegistry() *Registry {
+	r := NewRegistry()
+	js := NewJSParser()
+	r.Register(Language{Name: "go", Extensions: []string{".go", ".s", ".c"}, Parser: NewGoParser()})
+	r.Register(Language{Name: "javascript", Extensions: []string{".js", ".jsx", ".mjs", ".cjs"}, Shebangs: []string{"node"}, Parser: js})
+	r.Register(Language{Name: "typescript", Extensions: []string{".ts", ".tsx", ".mts", ".cts"}, Parser: js})
+	r.Register(Language{Name: "python", Extensions: []string{".py", ".pyi"}, Shebangs: []string{"python"}, Parser: NewPythonParser()})
+	r.Register(Language{Name: "java", Extensions: []string{".java"}, Parser: NewJavaParser()})
+	r.Register(Language{Name: "rust", Extensions: []string{".rs"}, Parser: NewRustParser()})
+	r.Register(Language{Name: "proto", Extensions: []string{".proto"}, Parser: NewProtoParser()})
+	r.Register(Language{Name: "sql", Extensions: []string{".sql"}, Parser: NewSQLParser()})
+	r.Register(Language{Name: "markdown", Extensions: []string{".md", ".markdown"}, Parser: NewMarkdownParser()})
+	r.Register(Language{Name: "openapi", Extensions: []string{".json", ".yaml", ".yml"}, Parser: NewOpenAPIParser()})
+	r.Register(Language{Name: "shell", Extensions: []string{".sh", ".bash"}, Shebangs: []string{"sh", "bash"}, Parser: NewShellParser()})
+	r.Register(Language{Name: "make", Extensions: []string{".mk"}, Filenames: []string{"Makefile", "makefile", "GNUmakefile"}, Parser: NewMakefileParser()})
+	return r
+}
+
+// Register adds a language. Earlier registrations win when two claim the same file.
+func (r *Registry) Register(lang Language) {
+	r.languages = append(r.languages, &lang)
+}
+
+// RegisterProfile registers the language of a regex parser profile
+func (r *Registry) RegisterProfile(profile Profile) error {
+	p, err := NewRegexParser(profile)
+	if err != nil {
+		return err
+	}
+	r.Register(Language{
+		Name:       profile.Language,
+		Extensions: profile.Extensions,
+		Filenames:  profile.Filenames,
+		Shebangs:   profile.Shebangs,
+		Parser:     p,
+	})
+	return nil
+}
+
+// SetLanguages activates only the named languages
+func (r *Registry) SetLanguages(names []string) {
+	r.active = make(map[string]bool, len(names))
+	for _, name := range names {
+		r.active[strings.ToLower(name)] = true
+	}
+}
+
+// Languages returns the names of the registered languages
+func (r *Registry) Languages() []string {
+	names := make([]string, len(r.languages))
+	for i, lang := range r.languages {
+		names[i] = lang.Name
+	}
+	return names
+}
+
+// isActive reports whether files of lang are parsed
+func (r *Registry) isActive(lang *Language) bool {
+	return r.active == nil || r.active[lang.Name]
+}
+
+// Patterns returns scanner include patterns matching the files of the active
+// languages by extension or name. Scripts recognized by their shebang have
+// no extension to match; see HasShebangs.
+func (r *Registry) Patterns() []string {
+	seen := make(map[string]bool)
+	patterns := make([]string, 0)
+	for _, lang := range r.languages {
+		if !r.isActive(lang) {
+			continue
+		}
+		for _, ext := range lang.Extensions {
+			if !seen["*"+ext] {
+				seen["*"+ext] = true
+				patterns = append(patterns, "*"+ext)
+			}
+		}
+		for _, name := range lang.Filenames {
+			if !seen[name] {
+				seen[name] = true
+				patterns = append(patterns, name)
+			}
+		}
+	}
+	sort.Strings(patterns)
+	return patterns
+}
+
+// HasShebangs reports whether an active language recognizes scripts by their shebang
+func (r *Registry) HasShebangs() bool {
+	for _, lang := range r.languages {
+		if r.isActive(lang) && len(lang.Shebangs) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Lookup returns the active language of a file, or nil. A file without an
+// extension is recognized by its name, then by the interpreter on its
+// shebang line, which is read from disk.
+func (r *Registry) Lookup(path string) *Language {
+	base := filepath.Base(path)
+	ext := filepath.Ext(base)
+	for _, lang := range r.languages {
+		if !r.isActive(lang) {
+			continue
+		}
+		for _, name := range lang.Filenames {
+			if name == base {
+				return lang
+			}
+		}
+		if ext == "" {
+			continue
+		}
+		for _, e := range lang.Extensions {
+			if e == ext {
+				return lang
+			}
+		}
+	}
+
+	if ext != "" || !r.HasShebangs() {
+		return nil
+	}
+	interpreter := shebangInterpreter(path)
+	if interpreter == "" {
+		return nil
+	}
+	for _, lang := range r.languages {
+		if !r.isActive(lang) {
+			continue
+		}
+		for _, name := range lang.Shebangs {
+			if name == interpreter {
+				return lang
+			}
+		}
+	}
+	return nil
+}
+
+// shebangInterpreter returns the interpreter a script's #! line runs, without
+// directory or version: "python" for "#!/usr/bin/env -S python3.12 -u"
+func shebangInterpreter(path string) string {
+	file, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	line, _ := bufio.NewReaderSize(file, 256).ReadSlice('\n')
+	if !strings.HasPrefix(string(line), "#!") {
+		return ""
+	}
+	fields := strings.Fields(string(line[2:]))
+	for len(fields) > 0 {
+		name := filepath.Base(fields[0])
+		fields = fields[1:]
+		if name == "env" || strings.HasPrefix(name, "-") || strings.Contains(name, "=") {
+			continue // env, its options and variables precede the interpreter
+		}
+		return strings.TrimRight(name, "0123456789.")
+	}
+	return ""
+}
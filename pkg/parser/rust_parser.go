@@ -0,0 +1,909 @@
+package parser
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// RustParser parses Rust source: functions, structs, enums, traits, impl
+// blocks and inline modules. Function bodies are skipped as balanced token
+// runs; macros are not expanded.
+type RustParser struct{}
+
+// NewRustParser creates a new Rust parser
+func NewRustParser() *RustParser {
+	return &RustParser{}
+}
+
+// SupportsFile checks if the parser supports this file
+func (p *RustParser) SupportsFile(filePath string) bool {
+	return filepath.Ext(filePath) == ".rs"
+}
+
+// Parse parses Rust source and extracts its items
+func (p *RustParser) Parse(filePath string, content []byte) (*ParseResult, error) {
+	src := string(content)
+	f := &rustFile{
+		tokenCursor: newTokenCursor(tokenizeRust(src), src, rustToken{srcToken: srcToken{pos: len(src), end: len(src)}}),
+		path:        filePath,
+		impls:       make(map[string]int),
+	}
+	f.items(rustModulePath(filePath), false)
+
+	for i := range f.elements {
+		el := &f.elements[i]
+		el.Imports = f.imports
+		el.Hash = HashCode(el.ID + "\n" + el.Body)
+	}
+	return &ParseResult{
+		Elements: f.elements,
+		Errors:   make([]ParseError, 0),
+	}, nil
+}
+
+// rustModulePath returns the module path of a file the way Cargo lays out
+// crates: src/config/mod.rs of crate my-cli is "my_cli::config". Files
+// outside src (tests, examples, build.rs) are crates of their own.
+func rustModulePath(path string) string {
+	parts := strings.Split(filepath.ToSlash(strings.TrimSuffix(path, ".rs")), "/")
+	src := -1
+	for i, part := range parts[:len(parts)-1] {
+		if part == "src" {
+			src = i
+		}
+	}
+	if src < 0 {
+		return strings.ReplaceAll(parts[len(parts)-1], "-", "_")
+	}
+
+	crate := "crate"
+	if src > 0 {
+		crate = strings.ReplaceAll(parts[src-1], "-", "_")
+	}
+	modules := parts[src+1:]
+	if len(modules) == 2 && modules[0] == "bin" { // src/bin/tool.rs is binary crate "tool"
+		return strings.ReplaceAll(modules[1], "-", "_")
+	}
+	switch modules[len(modules)-1] {
+	case "lib", "main", "mod":
+		modules = modules[:len(modules)-1]
+	}
+	return strings.Join(append([]string{crate}, modules...), "::")
+}
+
+// rustToken is a token with its byte range; doc is the outer doc comment
+// (/// or /** */) before it
+type rustToken struct {
+	srcToken
+	doc string
+}
+
+// rustPuncts are the multi-character punctuators that matter to the
+// parser. ">" is always a token of its own so that nested generics close.
+var rustPuncts = []string{"::", "->", "=>", "..=", "...", ".."}
+
+// tokenizeRust splits Rust source into tokens, dropping comments but
+// keeping each run of outer doc comments on the token that follows it
+func tokenizeRust(src string) []rustToken {
+	tokens := make([]rustToken, 0, len(src)/4)
+	doc := make([]string, 0)
+	for pos := 0; pos < len(src); {
+		c := src[pos]
+		start := pos
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			pos++
+			continue
+		case strings.HasPrefix(src[pos:], "//"):
+			end := strings.IndexByte(src[pos:], '\n')
+			if end < 0 {
+				end = len(src) - pos
+			}
+			if line := src[pos : pos+end]; strings.HasPrefix(line, "///") && !strings.HasPrefix(line, "////") {
+				doc = append(doc, strings.TrimPrefix(strings.TrimRight(line[3:], "\r"), " "))
+			}
+			pos += end
+			continue
+		case strings.HasPrefix(src[pos:], "/*"):
+			// Block comments nest
+			depth := 0
+			end := pos
+			for end < len(src) {
+				if strings.HasPrefix(src[end:], "/*") {
+					depth++
+					end += 2
+				} else if strings.HasPrefix(src[end:], "*/") {
+					end += 2
+					if depth--; depth == 0 {
+						break
+					}
+				} else {
+					end++
+				}
+			}
+			if comment := src[pos:end]; strings.HasPrefix(comment, "/**") && !strings.HasPrefix(comment, "/***") && comment != "/**/" {
+				doc = append(doc, docCommentText(comment))
+			}
+			pos = end
+			continue
+		case c == '"':
+			pos = skipRustString(src, pos+1)
+		case (c == 'r' || c == 'b' || c == 'c') && rustRawString(src[pos:]) > 0:
+			pos += rustRawString(src[pos:])
+		case (c == 'b' || c == 'c') && pos+1 < len(src) && src[pos+1] == '"':
+			pos = skipRustString(src, pos+2)
+		case c == '\'':
+			pos = skipRustChar(src, pos)
+		case c >= '0' && c <= '9':
+			for pos < len(src) && (isIdentByte(src[pos]) || src[pos] == '.' && pos+1 < len(src) && isDigit(src[pos+1])) {
+				pos++
+			}
+		case c == '_' || c >= 0x80 || unicode.IsLetter(rune(c)):
+			for pos < len(src) {
+				r, size := utf8.DecodeRuneInString(src[pos:])
+				if r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+					break
+				}
+				pos += size
+			}
+			if src[start:pos] == "r" && pos < len(src) && src[pos] == '#' { // Raw identifier (r#type)
+				pos++
+				for pos < len(src) && isIdentByte(src[pos]) {
+					pos++
+				}
+			}
+		default:
+			pos++
+			for _, p := range rustPuncts {
+				if strings.HasPrefix(src[start:], p) {
+					pos = start + len(p)
+					break
+				}
+			}
+		}
+		if pos > len(src) {
+			pos = len(src)
+		}
+		tokens = append(tokens, rustToken{srcToken: srcToken{text: src[start:pos], pos: start, end: pos}, doc: strings.Join(doc, "\n")})
+		doc = doc[:0]
+	}
+	return tokens
+}
+
+// skipRustString returns the offset after a string literal whose contents start at pos
+func skipRustString(src string, pos int) int {
+	for pos < len(src) {
+		switch src[pos] {
+		case '\\':
+			pos++
+		case '"':
+			return pos + 1
+		}
+		pos++
+	}
+	return len(src)
+}
+
+// rustRawString returns the length of the raw string literal (r#"..."#,
+// br"...") s starts with, or 0 if it does not start with one
+func rustRawString(s string) int {
+	i := 0
+	if s[0] == 'b' || s[0] == 'c' {
+		i++
+	}
+	if i >= len(s) || s[i] != 'r' {
+		return 0
+	}
+	i++
+	hashes := 0
+	for i < len(s) && s[i] == '#' {
+		hashes++
+		i++
+	}
+	if i >= len(s) || s[i] != '"' {
+		return 0
+	}
+	end := strings.Index(s[i+1:], "\""+strings.Repeat("#", hashes))
+	if end < 0 {
+		return len(s)
+	}
+	return i + 1 + end + 1 + hashes
+}
+
+// skipRustChar returns the offset after the character literal ('a', '\n')
+// or lifetime ('a) starting at pos
+func skipRustChar(src string, pos int) int {
+	pos++
+	if pos < len(src) && src[pos] == '\\' {
+		end := strings.IndexByte(src[min(pos+2, len(src)):], '\'')
+		if end < 0 {
+			return len(src)
+		}
+		return pos + 2 + end + 1
+	}
+	_, size := utf8.DecodeRuneInString(src[pos:])
+	if pos+size < len(src) && src[pos+size] == '\'' {
+		return pos + size + 1
+	}
+	for pos < len(src) && isIdentByte(src[pos]) {
+		pos++
+	}
+	return pos
+}
+
+// rustFile is the parsing state of one Rust file
+type rustFile struct {
+	tokenCursor[rustToken]
+	path     string
+	imports  []string
+	elements []CodeElement
+	impls    map[string]int // Impl IDs seen, to tell apart several impl blocks of one type
+}
+
+// rustItem is what precedes an item: its first token, doc comment,
+// attributes and visibility
+type rustItem struct {
+	start int
+	doc   string
+	attrs []string
+	pub   bool
+	async bool
+}
+
+// items parses the items of a module up to its closing brace (or the end
+// of the file). module is the module path items are declared in.
+func (f *rustFile) items(module string, braced bool) {
+	for f.i < len(f.tokens) {
+		if braced && f.accept("}") {
+			return
+		}
+		d := f.itemPrefix()
+		switch f.peek() {
+		case "fn":
+			f.function(d, module, nil)
+		case "struct", "union":
+			f.structDecl(d, module)
+		case "enum":
+			f.enumDecl(d, module)
+		case "trait":
+			f.traitDecl(d, module)
+		case "impl":
+			f.implBlock(d, module)
+		case "type":
+			f.typeAlias(d, module)
+		case "const", "static":
+			f.constant(d, module)
+		case "mod":
+			f.i++
+			name := f.peek()
+			f.i++
+			if f.accept("{") {
+				f.items(module+"::"+name, true)
+			} else {
+				f.accept(";")
+			}
+		case "use":
+			f.i++
+			start := f.i
+			f.skipTo(";")
+			f.imports = append(f.imports, strings.Join(strings.Fields(f.text(start, f.i)), ""))
+			f.accept(";")
+		case "extern":
+			f.i++
+			if f.accept("crate") {
+				f.skipTo(";")
+				f.accept(";")
+				continue
+			}
+			if strings.HasPrefix(f.peek(), "\"") {
+				f.i++ // ABI
+			}
+			if f.accept("{") {
+				f.items(module, true) // Foreign functions
+			}
+		case "{", "(", "[":
+			f.skipBalanced()
+		default:
+			f.i++ // Macro invocations and anything unknown
+		}
+	}
+}
+
+// itemPrefix consumes the attributes, visibility and qualifiers before an item
+func (f *rustFile) itemPrefix() rustItem {
+	d := rustItem{start: f.i, doc: f.tok(f.i).doc}
+	for f.i < len(f.tokens) {
+		switch f.peek() {
+		case "#":
+			inner := f.tok(f.i+1).text == "!"
+			if inner {
+				f.i++
+			}
+			f.i++
+			if f.peek() != "[" {
+				continue
+			}
+			start := f.i
+			f.skipBalanced()
+			if attr := f.text(start+1, f.i-1); !inner && !strings.HasPrefix(attr, "doc") {
+				d.attrs = append(d.attrs, attr)
+			}
+			if inner {
+				d.start = f.i
+			}
+		case "pub":
+			f.i++
+			if f.peek() == "(" { // pub(crate), pub(super): not exported from the crate
+				f.skipBalanced()
+			} else {
+				d.pub = true
+			}
+		case "async":
+			d.async = true
+			f.i++
+		case "unsafe", "default", "auto":
+			f.i++
+		case "const":
+			// const fn, not const X: T
+			switch f.tok(f.i + 1).text {
+			case "fn", "unsafe", "async", "extern":
+				f.i++
+			default:
+				return d
+			}
+		case "extern":
+			// extern "C" fn, not an extern block or crate
+			next := f.i + 1
+			if strings.HasPrefix(f.tok(next).text, "\"") {
+				next++
+			}
+			if f.tok(next).text != "fn" {
+				return d
+			}
+			f.i = next
+		default:
+			return d
+		}
+	}
+	return d
+}
+
+// add fills in the location and source of an element spanning tokens d.start to end and records it
+func (f *rustFile) add(el *CodeElement, d rustItem, module string, end int) int {
+	first, last := f.tok(d.start), f.tok(end-1)
+	el.File = f.path
+	el.Line = f.line(first.pos)
+	el.EndLine = f.line(last.end - 1)
+	el.ImportPath = module
+	el.Body = f.src[first.pos:last.end]
+	el.Docstring = d.doc
+	el.Language = "rust"
+	el.IndexedAt = time.Now()
+	f.elements = append(f.elements, *el)
+	return len(f.elements) - 1
+}
+
+// rustImpl is the impl block or trait a function is declared in
+type rustImpl struct {
+	typeName string // Self type ("Config"), or the trait for trait items
+	path     string // ID prefix of its functions: "Config", "<Config as Display>"
+	exported bool   // Trait items are as visible as the trait
+}
+
+// function parses a fn item; in is the impl block or trait it belongs to, if any
+func (f *rustFile) function(d rustItem, module string, in *rustImpl) string {
+	f.i++ // fn
+	name := f.peek()
+	f.i++
+
+	el := CodeElement{
+		Type:       TypeFunction,
+		Name:       name,
+		ID:         module + "::" + name,
+		Decorators: d.attrs,
+		Exports:    d.pub,
+		Async:      d.async,
+	}
+	for _, attr := range d.attrs {
+		switch attr {
+		case "test", "tokio::test":
+			el.Type = TypeTest
+		case "bench":
+			el.Type = TypeBenchmark
+		}
+	}
+	if in != nil {
+		el.Name = in.typeName + "." + name
+		el.ID = module + "::" + in.path + "::" + name
+		el.Exports = d.pub || in.exported
+	}
+
+	if f.peek() == "<" {
+		el.TypeParams = f.generics()
+	}
+	if f.peek() == "(" {
+		el.Params = f.params()
+	}
+	if f.accept("->") {
+		start := f.i
+		f.skipType()
+		el.Returns = f.text(start, f.i)
+	}
+	for f.i < len(f.tokens) && f.peek() != "{" && f.peek() != ";" && f.peek() != "}" {
+		f.i++ // where clause
+	}
+	if f.peek() == "{" {
+		f.skipBalanced()
+	} else {
+		f.accept(";")
+	}
+
+	f.add(&el, d, module, f.i)
+	return name
+}
+
+// generics parses <'a, T: Clone + Send, const N: usize, U = u32>
+func (f *rustFile) generics() []Parameter {
+	f.i++ // <
+	params := make([]Parameter, 0)
+	for f.i < len(f.tokens) && !f.accept(">") {
+		for f.peek() == "#" {
+			f.i++
+			f.skipBalanced()
+		}
+		f.accept("const")
+		param := Parameter{Name: f.peek()}
+		f.i++
+		if f.accept(":") {
+			start := f.i
+			f.skipBounds()
+			param.Type = f.text(start, f.i)
+		}
+		if f.accept("=") {
+			start := f.i
+			f.skipType()
+			param.Default = f.text(start, f.i)
+		}
+		params = append(params, param)
+		if !f.accept(",") && f.peek() != ">" {
+			f.i++
+		}
+	}
+	return params
+}
+
+// params parses a parenthesised parameter list, leaving out self
+func (f *rustFile) params() []Parameter {
+	f.i++ // (
+	params := make([]Parameter, 0)
+	for f.i < len(f.tokens) && !f.accept(")") {
+		for f.peek() == "#" {
+			f.i++
+			f.skipBalanced()
+		}
+		start := f.i
+		depth := 0
+		colon := -1
+	param:
+		for ; f.i < len(f.tokens); f.i++ {
+			switch f.peek() {
+			case "(", "[", "{", "<":
+				depth++
+			case ")", "]", "}", ">":
+				if depth == 0 {
+					break param
+				}
+				depth--
+			case ",":
+				if depth == 0 {
+					break param
+				}
+			case ":":
+				if depth == 0 && colon < 0 {
+					colon = f.i
+				}
+			}
+		}
+
+		if f.i == start { // A stray closing bracket
+			f.i++
+			continue
+		}
+		param := Parameter{Type: f.text(start, f.i)}
+		if colon >= 0 {
+			param = Parameter{Name: f.text(start, colon), Type: f.text(colon+1, f.i)}
+		}
+		if !isRustSelf(param) {
+			params = append(params, param)
+		}
+		f.accept(",")
+	}
+	return params
+}
+
+// isRustSelf reports whether a parameter is the receiver (self, &mut self, self: Box<Self>)
+func isRustSelf(p Parameter) bool {
+	pattern := p.Name
+	if pattern == "" {
+		pattern = p.Type
+	}
+	fields := strings.Fields(strings.NewReplacer("&", " ", "'", " '").Replace(pattern))
+	return len(fields) > 0 && fields[len(fields)-1] == "self"
+}
+
+// skipType skips a type up to the comma, bracket, brace, semicolon or
+// keyword ending it
+func (f *rustFile) skipType() {
+	depth := 0
+	for f.i < len(f.tokens) {
+		switch f.peek() {
+		case "<", "(", "[":
+			depth++
+		case ">", ")", "]":
+			if depth == 0 {
+				return
+			}
+			depth--
+		case "{":
+			if depth == 0 {
+				return
+			}
+			f.skipBalanced() // Const generic block argument
+			continue
+		case ",", "=":
+			if depth == 0 {
+				return
+			}
+		case ";", "}", "where", "for":
+			if depth == 0 && (f.peek() != "for" || f.tok(f.i+1).text != "<") {
+				return
+			}
+		}
+		f.i++
+	}
+}
+
+// skipBounds skips trait bounds (Clone + Send + 'a)
+func (f *rustFile) skipBounds() {
+	for {
+		start := f.i
+		f.skipType()
+		if f.i == start || !f.accept("+") {
+			return
+		}
+	}
+}
+
+// skipTo advances to the next text token outside brackets, stopping early
+// at a bracket closing the enclosing one
+func (f *rustFile) skipTo(text string) {
+	for f.i < len(f.tokens) && f.peek() != text {
+		switch f.peek() {
+		case "{", "(", "[":
+			f.skipBalanced()
+			continue
+		case "}", ")", "]":
+			return
+		}
+		f.i++
+	}
+}
+
+// structDecl parses a struct or union with named, tuple or no fields
+func (f *rustFile) structDecl(d rustItem, module string) {
+	f.i++ // struct
+	name := f.peek()
+	f.i++
+	el := CodeElement{
+		Type:       TypeStruct,
+		Name:       name,
+		ID:         module + "::" + name,
+		Decorators: d.attrs,
+		Exports:    d.pub,
+		Fields:     make([]Field, 0),
+	}
+	if f.peek() == "<" {
+		el.TypeParams = f.generics()
+	}
+	if f.peek() == "(" {
+		el.Fields = f.tupleFields()
+	}
+	for f.i < len(f.tokens) && f.peek() != "{" && f.peek() != ";" && f.peek() != "}" {
+		f.i++ // where clause
+	}
+	if f.peek() == "{" {
+		el.Fields = f.namedFields()
+	} else {
+		f.accept(";")
+	}
+	f.add(&el, d, module, f.i)
+}
+
+// namedFields parses { name: Type, ... }
+func (f *rustFile) namedFields() []Field {
+	f.i++ // {
+	fields := make([]Field, 0)
+	for f.i < len(f.tokens) && !f.accept("}") {
+		d := f.itemPrefix()
+		name := f.peek()
+		f.i++
+		if !f.accept(":") {
+			f.skipTo(",")
+			f.accept(",")
+			continue
+		}
+		start := f.i
+		f.skipType()
+		fields = append(fields, Field{Name: name, Type: f.text(start, f.i), Docstring: d.doc})
+		if !f.accept(",") && f.peek() != "}" {
+			f.i++
+		}
+	}
+	return fields
+}
+
+// tupleFields parses (Type, pub Type), naming the fields by position
+func (f *rustFile) tupleFields() []Field {
+	f.i++ // (
+	fields := make([]Field, 0)
+	for f.i < len(f.tokens) && !f.accept(")") {
+		d := f.itemPrefix()
+		start := f.i
+		f.skipType()
+		fields = append(fields, Field{Name: strconv.Itoa(len(fields)), Type: f.text(start, f.i), Docstring: d.doc})
+		if !f.accept(",") && f.peek() != ")" {
+			f.i++
+		}
+	}
+	return fields
+}
+
+// enumDecl parses an enum and the names of its variants
+func (f *rustFile) enumDecl(d rustItem, module string) {
+	f.i++ // enum
+	name := f.peek()
+	f.i++
+	el := CodeElement{
+		Type:       TypeType,
+		Name:       name,
+		ID:         module + "::" + name,
+		Decorators: d.attrs,
+		Exports:    d.pub,
+		EnumValues: make([]string, 0),
+	}
+	if f.peek() == "<" {
+		el.TypeParams = f.generics()
+	}
+	f.skipTo("{")
+	f.i++
+	for f.i < len(f.tokens) && !f.accept("}") {
+		f.itemPrefix()
+		el.EnumValues = append(el.EnumValues, f.peek())
+		f.i++
+		f.skipTo(",")
+		f.accept(",")
+	}
+	f.add(&el, d, module, f.i)
+}
+
+// traitDecl parses a trait, its supertraits and its items
+func (f *rustFile) traitDecl(d rustItem, module string) {
+	f.i++ // trait
+	name := f.peek()
+	f.i++
+	el := CodeElement{
+		Type:       TypeInterface,
+		Name:       name,
+		ID:         module + "::" + name,
+		Decorators: d.attrs,
+		Exports:    d.pub,
+		Methods:    make([]string, 0),
+	}
+	if f.peek() == "<" {
+		el.TypeParams = f.generics()
+	}
+	if f.accept(":") {
+		start := f.i
+		f.skipBounds()
+		el.Extends = f.text(start, f.i)
+	}
+	for f.i < len(f.tokens) && f.peek() != "{" && f.peek() != ";" {
+		f.i++ // where clause
+	}
+	if f.peek() == ";" { // Trait alias
+		f.i++
+		f.add(&el, d, module, f.i)
+		return
+	}
+
+	index := f.add(&el, d, module, f.i) // Body and end line are fixed up after the items
+	el.Methods = f.implItems(module, &rustImpl{typeName: name, path: name, exported: d.pub})
+	f.finish(index, d, el.Methods)
+}
+
+// implBlock parses impl [Trait for] Type { ... } into an element for the
+// block; its functions are recorded as methods of the type
+func (f *rustFile) implBlock(d rustItem, module string) {
+	f.i++ // impl
+	el := CodeElement{
+		Type:       TypeImpl,
+		Decorators: d.attrs,
+		Methods:    make([]string, 0),
+	}
+	if f.peek() == "<" {
+		el.TypeParams = f.generics()
+	}
+
+	start := f.i
+	f.accept("!")
+	f.skipType()
+	selfType := f.text(start, f.i)
+	trait := ""
+	if f.accept("for") {
+		trait = selfType
+		start = f.i
+		f.skipType()
+		selfType = f.text(start, f.i)
+	}
+	for f.i < len(f.tokens) && f.peek() != "{" && f.peek() != ";" {
+		f.i++ // where clause
+	}
+
+	el.Name = rustTypeName(selfType)
+	el.ID = module + "::<impl " + selfType + ">"
+	path := el.Name
+	if trait != "" {
+		el.Implements = []string{trait}
+		el.ID = module + "::<" + selfType + " as " + trait + ">"
+		path = "<" + selfType + " as " + trait + ">"
+	}
+	// A type may have several inherent impl blocks in a module
+	if f.impls[el.ID]++; f.impls[el.ID] > 1 {
+		el.ID += "#" + strconv.Itoa(f.impls[el.ID])
+	}
+	if f.peek() != "{" {
+		f.accept(";")
+		f.add(&el, d, module, f.i)
+		return
+	}
+
+	index := f.add(&el, d, module, f.i)
+	methods := f.implItems(module, &rustImpl{typeName: el.Name, path: path, exported: trait != ""})
+	f.finish(index, d, methods)
+}
+
+// implItems parses the items of an impl block or trait body and returns the function names
+func (f *rustFile) implItems(module string, in *rustImpl) []string {
+	f.i++ // {
+	methods := make([]string, 0)
+	for f.i < len(f.tokens) && !f.accept("}") {
+		d := f.itemPrefix()
+		switch f.peek() {
+		case "fn":
+			methods = append(methods, f.function(d, module, in))
+		case "{", "(", "[":
+			f.skipBalanced()
+		case "type", "const":
+			f.skipTo(";")
+			f.accept(";")
+		default:
+			f.i++ // Macro invocations
+		}
+	}
+	return methods
+}
+
+// finish extends a trait or impl element to the end of its body and sets its methods
+func (f *rustFile) finish(index int, d rustItem, methods []string) {
+	el := &f.elements[index]
+	end := f.tok(f.i - 1)
+	el.Body = f.src[f.tok(d.start).pos:end.end]
+	el.EndLine = f.line(end.end - 1)
+	el.Methods = methods
+}
+
+// typeAlias parses type Name<T> = Type;
+func (f *rustFile) typeAlias(d rustItem, module string) {
+	f.i++ // type
+	name := f.peek()
+	f.i++
+	el := CodeElement{
+		Type:       TypeType,
+		Name:       name,
+		ID:         module + "::" + name,
+		Decorators: d.attrs,
+		Exports:    d.pub,
+	}
+	if f.peek() == "<" {
+		el.TypeParams = f.generics()
+	}
+	f.skipTo(";")
+	f.accept(";")
+	f.add(&el, d, module, f.i)
+}
+
+// constant parses const NAME: Type = value; and static [mut] NAME: Type = value;
+func (f *rustFile) constant(d rustItem, module string) {
+	el := CodeElement{
+		Type:       TypeConstant,
+		Decorators: d.attrs,
+		Exports:    d.pub,
+	}
+	if f.peek() == "static" {
+		el.Type = TypeVariable
+	}
+	f.i++
+	f.accept("mut")
+	el.Name = f.peek()
+	el.ID = module + "::" + el.Name
+	f.i++
+	if f.accept(":") {
+		start := f.i
+		f.skipType()
+		el.ValueType = f.text(start, f.i)
+	}
+	if f.accept("=") {
+		start := f.i
+		f.skipTo(";")
+		if value := f.text(start, f.i); !strings.Contains(value, "\n") {
+			el.Value = value
+		}
+	}
+	f.accept(";")
+	f.add(&el, d, module, f.i)
+}
+
+// rustTypeName returns the name of the type an impl block is for:
+// "Config" for &'a mut crate::config::Config<T>
+func rustTypeName(typ string) string {
+	if i := strings.IndexByte(typ, '<'); i >= 0 {
+		typ = typ[:i]
+	}
+	if i := strings.LastIndex(typ, "::"); i >= 0 {
+		typ = typ[i+2:]
+	}
+	fields := strings.Fields(strings.NewReplacer("&", " ", "*", " ").Replace(typ))
+	if len(fields) == 0 {
+		return typ
+	}
+	return fields[len(fields)-1]
+}
+
+// linkImpls attaches the methods and traits of Rust impl blocks to the
+// struct, enum or trait they are for. The type is looked up in the module of
+// the block first, then by name in its crate, as impls may sit elsewhere.
+func linkImpls(elements []CodeElement) {
+	inModule := make(map[string]*CodeElement) // "crate::module.Name"
+	inCrate := make(map[string][]*CodeElement)
+	for i := range elements {
+		el := &elements[i]
+		if el.Language != "rust" || el.Type == TypeImpl || el.Type.IsFunction() || el.Type == TypeConstant || el.Type == TypeVariable {
+			continue
+		}
+		inModule[el.ImportPath+"."+el.Name] = el
+		crate := strings.SplitN(el.ImportPath, "::", 2)[0]
+		inCrate[crate+"."+el.Name] = append(inCrate[crate+"."+el.Name], el)
+	}
+
+	for _, impl := range elements {
+		if impl.Type != TypeImpl {
+			continue
+		}
+		typ := inModule[impl.ImportPath+"."+impl.Name]
+		if typ == nil {
+			crate := strings.SplitN(impl.ImportPath, "::", 2)[0]
+			if candidates := inCrate[crate+"."+impl.Name]; len(candidates) == 1 {
+				typ = candidates[0]
+			}
+		}
+		if typ == nil {
+			continue
+		}
+		typ.Methods = append(typ.Methods, impl.Methods...)
+		typ.Implements = append(typ.Implements, impl.Implements...)
+	}
+}
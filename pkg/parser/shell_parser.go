@@ -0,0 +1,211 @@
+package parser
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ShellParser parses sh and bash scripts: their functions, and the files
+// they source
+type ShellParser struct{}
+
+// NewShellParser creates a new shell script parser
+func NewShellParser() *ShellParser {
+	return &ShellParser{}
+}
+
+// SupportsFile checks if the parser supports this file
+func (p *ShellParser) SupportsFile(filePath string) bool {
+	switch filepath.Ext(filePath) {
+	case ".sh", ".bash":
+		return true
+	}
+	return false
+}
+
+var (
+	// shellFunction matches a function definition: name() {, function name {, function name() (
+	shellFunction = regexp.MustCompile(`^\s*(?:function\s+([\w.:@+-]+)\s*(?:\(\s*\))?|([\w.:@+-]+)\s*\(\s*\))\s*([{(]|$)`)
+
+	// shellSource matches a source or . command and its file
+	shellSource = regexp.MustCompile(`(?:^|[;&|]|\bthen|\bdo|\belse)\s*(?:source|\.)\s+((?:"[^"]*"|'[^']*'|[^\s;&|)])+)`)
+
+	// shellHeredoc matches the start of a here-document and its delimiter
+	shellHeredoc = regexp.MustCompile(`<<-?\s*(?:'([^']+)'|"([^"]+)"|\\?([\w.-]+))`)
+)
+
+// Parse extracts the functions of a script, and the script itself as a
+// package element listing the files it sources
+func (p *ShellParser) Parse(filePath string, content []byte) (*ParseResult, error) {
+	src := strings.ReplaceAll(string(content), "\r\n", "\n")
+	lines := strings.Split(src, "\n")
+	module := strings.TrimSuffix(filepath.ToSlash(filePath), filepath.Ext(filePath))
+	code := shellCode(lines)
+
+	imports := make([]string, 0)
+	elements := make([]CodeElement, 0)
+	defined := make(map[string]bool)
+	for i := 0; i < len(lines); i++ {
+		if !code[i] {
+			continue
+		}
+		for _, m := range shellSource.FindAllStringSubmatch(stripLineComment(lines[i], "#"), -1) {
+			imports = append(imports, strings.NewReplacer(`"`, "", "'", "").Replace(m[1]))
+		}
+		m := shellFunction.FindStringSubmatchIndex(lines[i])
+		if m == nil {
+			continue
+		}
+		name := lines[i][max(m[2], m[4]):max(m[3], m[5])]
+		if defined[name] { // A redefinition; the first one keeps the ID
+			continue
+		}
+		defined[name] = true
+		end := shellBlockEnd(lines, code, i, m[6])
+		elements = append(elements, CodeElement{
+			Type:       TypeFunction,
+			Name:       name,
+			ID:         module + "." + name,
+			File:       filePath,
+			Line:       i + 1,
+			EndLine:    end + 1,
+			ImportPath: module,
+			Params:     make([]Parameter, 0),
+			Body:       strings.Join(lines[i:end+1], "\n"),
+			Docstring:  lineCommentAbove(lines, i, "#"),
+			Exports:    true,
+			Language:   "shell",
+			IndexedAt:  time.Now(),
+		})
+	}
+
+	// Calls: the script's functions run as commands in a body
+	commands := make([]*regexp.Regexp, len(elements))
+	for i, fn := range elements {
+		commands[i] = regexp.MustCompile(`(?m)(?:^|[;&|({` + "`" + `!]|\$\(|\b(?:then|do|else|if|while|until)\s)\s*` +
+			regexp.QuoteMeta(fn.Name) + `(?:[\s;&|)]|$)`)
+	}
+	for i := range elements {
+		el := &elements[i]
+		el.Imports = imports
+		body := el.Body
+		if nl := strings.IndexByte(body, '\n'); nl >= 0 {
+			body = body[nl+1:] // Not the definition line
+		}
+		for j, callee := range elements {
+			if j != i && commands[j].MatchString(body) {
+				el.Calls = append(el.Calls, callee.ID)
+			}
+		}
+		el.Hash = HashCode(el.ID + "\n" + el.Body)
+	}
+
+	// The script: its header comment, and what it sources
+	header := shellHeader(lines)
+	script := CodeElement{
+		Type:            TypePackage,
+		Name:            filepath.Base(module),
+		ID:              module,
+		File:            filePath,
+		Line:            1,
+		EndLine:         1 + strings.Count(header, "\n"),
+		Body:            header,
+		Docstring:       lineCommentAbove(lines, strings.Count(header, "\n")+1, "#"),
+		ImportPath:      module,
+		Files:           []string{filePath},
+		ExportedSymbols: len(elements),
+		Imports:         imports,
+		Language:        "shell",
+		IndexedAt:       time.Now(),
+	}
+	script.Hash = HashCode(script.ID + "\n" + script.Body)
+
+	return &ParseResult{
+		Elements: append([]CodeElement{script}, elements...),
+		Errors:   make([]ParseError, 0),
+	}, nil
+}
+
+// shellCode reports which lines are commands, not here-document text
+func shellCode(lines []string) []bool {
+	code := make([]bool, len(lines))
+	delimiter := ""
+	for i, line := range lines {
+		if delimiter != "" {
+			if strings.TrimLeft(line, "\t") == delimiter {
+				delimiter = ""
+			}
+			continue
+		}
+		code[i] = true
+		if m := shellHeredoc.FindStringSubmatch(stripLineComment(line, "#")); m != nil && !strings.Contains(line, "<<<") {
+			delimiter = m[1] + m[2] + m[3]
+		}
+	}
+	return code
+}
+
+// shellBlockEnd returns the line closing the body of the function defined
+// on line start, whose definition ends at column col: the brace (or
+// parenthesis) matching the one opening it, skipping quoted text, comments
+// and here-documents
+func shellBlockEnd(lines []string, code []bool, start, col int) int {
+	depth := 0
+	opened := false
+	for i := start; i < len(lines); i++ {
+		if !code[i] {
+			continue
+		}
+		line := lines[i]
+		if i == start {
+			line = line[col:]
+		}
+		var quote byte
+		for j := 0; j < len(line); j++ {
+			c := line[j]
+			switch {
+			case quote != 0:
+				if c == '\\' && quote == '"' {
+					j++
+				} else if c == quote {
+					quote = 0
+				}
+			case c == '\\':
+				j++
+			case c == '"' || c == '\'':
+				quote = c
+			case c == '#' && (j == 0 || line[j-1] == ' ' || line[j-1] == '\t'):
+				j = len(line)
+			case c == '{' || c == '(':
+				depth++
+				opened = true
+			case c == '}' || c == ')':
+				depth--
+				if opened && depth == 0 {
+					return i
+				}
+			}
+		}
+		if !opened && i > start+1 { // The body opens on the definition line or the next
+			return start
+		}
+	}
+	if opened {
+		return len(lines) - 1
+	}
+	return start
+}
+
+// shellHeader returns the shebang line and the comment block after it
+func shellHeader(lines []string) string {
+	end := 0
+	for end < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[end]), "#") {
+		end++
+	}
+	if end == 0 {
+		return ""
+	}
+	return strings.Join(lines[:end], "\n")
+}
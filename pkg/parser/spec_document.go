@@ -0,0 +1,517 @@
+package parser
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// specKind is the kind of a specNode
+type specKind int
+
+const (
+	specScalar specKind = iota
+	specMap
+	specList
+)
+
+// specNode is a node of a JSON or YAML document: a mapping (keys kept in
+// document order), a sequence or a scalar, with the lines it spans
+type specNode struct {
+	kind   specKind
+	keys   []string
+	fields map[string]*specNode
+	items  []*specNode
+	value  string
+	line   int
+	end    int
+}
+
+// get returns the value of a mapping key, or nil
+func (n *specNode) get(key string) *specNode {
+	if n == nil || n.kind != specMap {
+		return nil
+	}
+	return n.fields[key]
+}
+
+// str returns the scalar value of a mapping key, or ""
+func (n *specNode) str(key string) string {
+	if v := n.get(key); v != nil && v.kind == specScalar {
+		return v.value
+	}
+	return ""
+}
+
+// set adds a mapping key; a repeated key replaces the earlier value
+func (n *specNode) set(key string, value *specNode) {
+	if _, ok := n.fields[key]; !ok {
+		n.keys = append(n.keys, key)
+	}
+	n.fields[key] = value
+}
+
+// pointer resolves a local JSON pointer ("#/components/schemas/User")
+func (n *specNode) pointer(ref string) *specNode {
+	if !strings.HasPrefix(ref, "#/") {
+		return nil
+	}
+	for _, part := range strings.Split(ref[2:], "/") {
+		part = strings.ReplaceAll(strings.ReplaceAll(part, "~1", "/"), "~0", "~")
+		if n == nil {
+			return nil
+		}
+		if n.kind == specList {
+			i, err := strconv.Atoi(part)
+			if err != nil || i < 0 || i >= len(n.items) {
+				return nil
+			}
+			n = n.items[i]
+			continue
+		}
+		n = n.get(part)
+	}
+	return n
+}
+
+// parseJSONDocument decodes a JSON document into nodes
+func parseJSONDocument(src []byte) (*specNode, error) {
+	lines := lineStarts(string(src))
+	dec := json.NewDecoder(strings.NewReader(string(src)))
+	dec.UseNumber()
+	line := func(offset int64) int {
+		// The decoder's offset is before separating whitespace, commas and colons
+		for offset < int64(len(src)) && strings.IndexByte(" \t\r\n,:", src[offset]) >= 0 {
+			offset++
+		}
+		return lines.line(int(offset))
+	}
+
+	var decode func() (*specNode, error)
+	decode = func() (*specNode, error) {
+		start := line(dec.InputOffset())
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		n := &specNode{line: start}
+		switch t := tok.(type) {
+		case json.Delim:
+			if t == '{' {
+				n.kind, n.fields = specMap, make(map[string]*specNode)
+				for dec.More() {
+					key, err := dec.Token()
+					if err != nil {
+						return nil, err
+					}
+					value, err := decode()
+					if err != nil {
+						return nil, err
+					}
+					n.set(key.(string), value)
+				}
+			} else {
+				n.kind = specList
+				for dec.More() {
+					item, err := decode()
+					if err != nil {
+						return nil, err
+					}
+					n.items = append(n.items, item)
+				}
+			}
+			n.end = line(dec.InputOffset())
+			if _, err := dec.Token(); err != nil { // The closing delimiter
+				return nil, err
+			}
+			return n, nil
+		case string:
+			n.value = t
+		case json.Number:
+			n.value = t.String()
+		case bool:
+			n.value = strconv.FormatBool(t)
+		case nil:
+			n.value = "null"
+		}
+		n.end = n.line
+		return n, nil
+	}
+	return decode()
+}
+
+// yamlLine is a line of a YAML document; text is its content without
+// indentation and comment ("" for blank and comment lines)
+type yamlLine struct {
+	indent int
+	text   string
+	raw    string
+	line   int
+}
+
+// yamlParser reads the block structure of a YAML document. It covers what
+// API specifications use: block mappings and sequences, flow collections,
+// quoted, plain and block scalars. Anchors and tags are ignored.
+type yamlParser struct {
+	lines []yamlLine
+	i     int
+}
+
+// parseYAMLDocument decodes the first document of a YAML stream into nodes
+func parseYAMLDocument(src []byte) *specNode {
+	p := &yamlParser{}
+	for i, raw := range strings.Split(string(src), "\n") {
+		raw = strings.TrimRight(raw, "\r")
+		trimmed := strings.TrimSpace(raw)
+		if trimmed == "---" || strings.HasPrefix(trimmed, "--- ") || trimmed == "..." || strings.HasPrefix(trimmed, "%") {
+			if len(p.lines) > 0 && trimmed != "..." && !strings.HasPrefix(trimmed, "%") {
+				break // A second document
+			}
+			continue
+		}
+		p.lines = append(p.lines, yamlLine{
+			indent: len(raw) - len(strings.TrimLeft(raw, " ")),
+			text:   strings.TrimSpace(stripYAMLComment(raw)),
+			raw:    raw,
+			line:   i + 1,
+		})
+	}
+	l, ok := p.peek()
+	if !ok {
+		return nil
+	}
+	return p.block(l.indent)
+}
+
+// stripYAMLComment cuts a line at a comment: # at its start or after a space, outside quotes
+func stripYAMLComment(line string) string {
+	var quote byte
+	for j := 0; j < len(line); j++ {
+		c := line[j]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case (c == '"' || c == '\'') && (j == 0 || strings.IndexByte(" \t[{,:-", line[j-1]) >= 0):
+			quote = c
+		case c == '#' && (j == 0 || line[j-1] == ' ' || line[j-1] == '\t'):
+			return line[:j]
+		}
+	}
+	return line
+}
+
+// peek skips blank and comment lines and returns the current line
+func (p *yamlParser) peek() (yamlLine, bool) {
+	for p.i < len(p.lines) && p.lines[p.i].text == "" {
+		p.i++
+	}
+	if p.i >= len(p.lines) {
+		return yamlLine{}, false
+	}
+	return p.lines[p.i], true
+}
+
+// lastLine returns the line number of the last consumed line
+func (p *yamlParser) lastLine() int {
+	for i := p.i - 1; i >= 0; i-- {
+		if p.lines[i].text != "" {
+			return p.lines[i].line
+		}
+	}
+	return 1
+}
+
+// isYAMLItem reports whether a line is a sequence item
+func isYAMLItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+// block parses the mapping or sequence starting at the current line
+func (p *yamlParser) block(indent int) *specNode {
+	if l, _ := p.peek(); isYAMLItem(l.text) {
+		return p.sequence(indent)
+	}
+	return p.mapping(indent)
+}
+
+// mapping parses the key: value lines at indent
+func (p *yamlParser) mapping(indent int) *specNode {
+	l, _ := p.peek()
+	n := &specNode{kind: specMap, fields: make(map[string]*specNode), line: l.line}
+	for {
+		l, ok := p.peek()
+		if !ok || l.indent < indent || l.indent == indent && isYAMLItem(l.text) {
+			break
+		}
+		p.i++
+		key, rest, ok := yamlKey(l.text)
+		if l.indent > indent || !ok {
+			continue // Not part of the structure we understand
+		}
+		n.set(key, p.value(indent, rest, l.line))
+	}
+	n.end = p.lastLine()
+	return n
+}
+
+// sequence parses the "- item" lines at indent
+func (p *yamlParser) sequence(indent int) *specNode {
+	l, _ := p.peek()
+	n := &specNode{kind: specList, line: l.line}
+	for {
+		l, ok := p.peek()
+		if !ok || l.indent < indent || l.indent == indent && !isYAMLItem(l.text) {
+			break
+		}
+		if l.indent > indent {
+			p.i++
+			continue
+		}
+		rest := strings.TrimSpace(l.text[1:])
+		if _, _, isKey := yamlKey(rest); isKey || isYAMLItem(rest) {
+			// "- key: value" opens a mapping indented at the column of key
+			column := l.indent + strings.Index(l.raw[l.indent:], rest)
+			p.lines[p.i] = yamlLine{indent: column, text: rest, raw: l.raw, line: l.line}
+			n.items = append(n.items, p.block(column))
+			continue
+		}
+		p.i++
+		n.items = append(n.items, p.value(indent, rest, l.line))
+	}
+	n.end = p.lastLine()
+	return n
+}
+
+// value parses the value of a mapping key or sequence item whose line is
+// indented at indent, rest being the text after the key or dash
+func (p *yamlParser) value(indent int, rest string, line int) *specNode {
+	for strings.HasPrefix(rest, "&") || strings.HasPrefix(rest, "!") { // Anchors and tags
+		_, rest, _ = strings.Cut(rest, " ")
+		rest = strings.TrimSpace(rest)
+	}
+
+	switch {
+	case rest == "":
+		if l, ok := p.peek(); ok && (l.indent > indent || l.indent == indent && isYAMLItem(l.text)) {
+			n := p.block(l.indent)
+			n.line = line // The node starts with its key
+			return n
+		}
+		return &specNode{line: line, end: line}
+	case rest[0] == '|' || rest[0] == '>':
+		return p.blockScalar(indent, rest[0] == '>', line)
+	case rest[0] == '[' || rest[0] == '{':
+		// Flow collections may continue on the lines below
+		text := rest
+		for !yamlBalanced(text) && p.i < len(p.lines) {
+			text += " " + strings.TrimSpace(stripYAMLComment(p.lines[p.i].raw))
+			p.i++
+		}
+		f := &yamlFlow{s: text, line: line}
+		n := f.node()
+		n.end = p.lastLine()
+		if n.end < line {
+			n.end = line
+		}
+		return n
+	}
+
+	// Plain scalars may continue on more indented lines
+	text := rest
+	if rest[0] != '"' && rest[0] != '\'' {
+		for {
+			l, ok := p.peek()
+			if !ok || l.indent <= indent {
+				break
+			}
+			text += " " + l.text
+			p.i++
+		}
+	}
+	return &specNode{value: yamlScalar(text), line: line, end: p.lastLine()}
+}
+
+// blockScalar parses the lines of a | or > scalar, indented deeper than indent
+func (p *yamlParser) blockScalar(indent int, folded bool, line int) *specNode {
+	lines := make([]string, 0)
+	block := -1
+	for p.i < len(p.lines) {
+		l := p.lines[p.i]
+		if strings.TrimSpace(l.raw) != "" && l.indent <= indent {
+			break
+		}
+		if block < 0 && strings.TrimSpace(l.raw) != "" {
+			block = l.indent
+		}
+		text := ""
+		if block >= 0 && len(l.raw) > block {
+			text = l.raw[block:]
+		}
+		lines = append(lines, text)
+		p.i++
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+		p.i--
+	}
+	sep := "\n"
+	if folded {
+		sep = " "
+	}
+	return &specNode{value: strings.Join(lines, sep), line: line, end: line + len(lines)}
+}
+
+// yamlKey splits "key: value" into its key and the rest of the line
+func yamlKey(text string) (key, rest string, ok bool) {
+	if text == "" || strings.IndexByte("[{-#&*!|>%@`", text[0]) >= 0 && !(text[0] == '-' && len(text) > 1 && text[1] != ' ') {
+		return "", "", false
+	}
+	end := 0
+	if text[0] == '"' || text[0] == '\'' {
+		end = strings.IndexByte(text[1:], text[0]) + 2
+		if end < 2 || end >= len(text) || text[end] != ':' {
+			return "", "", false
+		}
+	} else {
+		end = strings.Index(text, ": ")
+		if end < 0 {
+			if !strings.HasSuffix(text, ":") {
+				return "", "", false
+			}
+			end = len(text) - 1
+		}
+	}
+	return yamlScalar(text[:end]), strings.TrimSpace(text[end+1:]), true
+}
+
+// yamlScalar returns the value of a plain or quoted scalar
+func yamlScalar(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) >= 2 && s[0] == '\'' && s[len(s)-1] == '\'' {
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'")
+	}
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		if unquoted, err := strconv.Unquote(s); err == nil {
+			return unquoted
+		}
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// yamlBalanced reports whether the brackets of a flow collection are closed
+func yamlBalanced(s string) bool {
+	depth := 0
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '[' || c == '{':
+			depth++
+		case c == ']' || c == '}':
+			depth--
+		}
+	}
+	return depth <= 0
+}
+
+// yamlFlow parses a flow collection ([a, b], {k: v})
+type yamlFlow struct {
+	s    string
+	i    int
+	line int
+}
+
+// skip skips spaces
+func (f *yamlFlow) skip() {
+	for f.i < len(f.s) && (f.s[f.i] == ' ' || f.s[f.i] == '\t') {
+		f.i++
+	}
+}
+
+// node parses the node at the current position
+func (f *yamlFlow) node() *specNode {
+	f.skip()
+	n := &specNode{line: f.line, end: f.line}
+	if f.i >= len(f.s) {
+		return n
+	}
+	switch f.s[f.i] {
+	case '[':
+		n.kind = specList
+		f.i++
+		for {
+			f.skip()
+			if f.i >= len(f.s) || f.s[f.i] == ']' {
+				f.i++
+				return n
+			}
+			start := f.i
+			n.items = append(n.items, f.node())
+			f.skip()
+			if f.i < len(f.s) && f.s[f.i] == ',' {
+				f.i++
+			} else if f.i == start {
+				f.i++
+			}
+		}
+	case '{':
+		n.kind, n.fields = specMap, make(map[string]*specNode)
+		f.i++
+		for {
+			f.skip()
+			if f.i >= len(f.s) || f.s[f.i] == '}' {
+				f.i++
+				return n
+			}
+			start := f.i
+			key := f.scalar(":,}")
+			f.skip()
+			value := &specNode{line: f.line, end: f.line}
+			if f.i < len(f.s) && f.s[f.i] == ':' {
+				f.i++
+				value = f.node()
+			}
+			n.set(key, value)
+			f.skip()
+			if f.i < len(f.s) && f.s[f.i] == ',' {
+				f.i++
+			} else if f.i == start {
+				f.i++
+			}
+		}
+	}
+	n.value = f.scalar(",]}")
+	return n
+}
+
+// scalar parses a quoted scalar, or a plain one up to one of the stop characters
+func (f *yamlFlow) scalar(stop string) string {
+	start := f.i
+	if c := f.s[f.i]; c == '"' || c == '\'' {
+		f.i++
+		for f.i < len(f.s) && f.s[f.i] != c {
+			if f.s[f.i] == '\\' && c == '"' {
+				f.i++
+			}
+			f.i++
+		}
+		f.i++
+		if f.i > len(f.s) {
+			f.i = len(f.s)
+		}
+		return yamlScalar(f.s[start:f.i])
+	}
+	for f.i < len(f.s) && strings.IndexByte(stop, f.s[f.i]) < 0 {
+		f.i++
+	}
+	return yamlScalar(f.s[start:f.i])
+}
@@ -0,0 +1,1062 @@
+package parser
+
+import (
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SQLParser parses SQL schema files and migrations into the tables,
+// views, indexes and stored functions they define. Migrations are applied
+// in order, so ALTER and DROP statements shape the final schema.
+type SQLParser struct{}
+
+// NewSQLParser creates a new SQL parser
+func NewSQLParser() *SQLParser {
+	return &SQLParser{}
+}
+
+// SupportsFile checks if the parser supports this file
+func (p *SQLParser) SupportsFile(filePath string) bool {
+	return filepath.Ext(filePath) == ".sql"
+}
+
+// Parse parses a single SQL file as if applied to an empty database
+func (p *SQLParser) Parse(filePath string, content []byte) (*ParseResult, error) {
+	return p.ParseAll([]SourceFile{{Path: filePath, Content: content}})
+}
+
+// ParseAll applies SQL files in migration order (see SortMigrations) and
+// returns the resulting schemas, one per directory: a fixture elsewhere in
+// the tree does not drop or replace the tables of a migrations directory.
+// Down migrations are left out.
+func (p *SQLParser) ParseAll(files []SourceFile) (*ParseResult, error) {
+	files = append([]SourceFile(nil), files...)
+	paths := make([]string, len(files))
+	byPath := make(map[string]SourceFile, len(files))
+	for i, file := range files {
+		paths[i] = file.Path
+		byPath[file.Path] = file
+	}
+	SortMigrations(paths)
+
+	elements := make([]CodeElement, 0)
+	var s *sqlSchema
+	for i, path := range paths {
+		if i == 0 || filepath.Dir(path) != filepath.Dir(paths[i-1]) {
+			if s != nil {
+				elements = append(elements, s.elements()...)
+			}
+			s = &sqlSchema{objects: make(map[string]*CodeElement), altered: make(map[*CodeElement]bool)}
+		}
+		if isDownMigration(path) {
+			continue
+		}
+		s.apply(path, byPath[path].Content)
+	}
+	if s != nil {
+		elements = append(elements, s.elements()...)
+	}
+	return &ParseResult{
+		Elements: elements,
+		Errors:   make([]ParseError, 0),
+	}, nil
+}
+
+// sqlID returns the ID of an object of the schema built from the files of
+// the directory of file: "sql:db/migrations/users", "sql:users" at the root
+func sqlID(file, name string) string {
+	return "sql:" + path.Join(filepath.ToSlash(filepath.Dir(file)), name)
+}
+
+// isDownMigration reports whether a file reverts a migration (0002_users.down.sql)
+func isDownMigration(path string) bool {
+	base := strings.ToLower(filepath.Base(path))
+	for _, marker := range []string{".down.", "_down.", "-down."} {
+		if strings.Contains(base, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// migrationVersion matches the version a migration file name starts with:
+// 0001_init.sql, 20240101120000_users.up.sql, V2__add_email.sql
+var migrationVersion = regexp.MustCompile(`^[Vv]?(\d+)`)
+
+// SortMigrations sorts SQL file paths in the order they apply: by
+// directory, then by the version their name starts with, then by name.
+// Files without a version (schema.sql) come first in their directory.
+func SortMigrations(paths []string) {
+	version := func(path string) string {
+		m := migrationVersion.FindStringSubmatch(filepath.Base(path))
+		if m == nil {
+			return ""
+		}
+		return strings.TrimLeft(m[1], "0")
+	}
+	sort.SliceStable(paths, func(i, j int) bool {
+		a, b := paths[i], paths[j]
+		if da, db := filepath.Dir(a), filepath.Dir(b); da != db {
+			return da < db
+		}
+		va, vb := version(a), version(b)
+		if len(va) != len(vb) {
+			return len(va) < len(vb)
+		}
+		if va != vb {
+			return va < vb
+		}
+		return a < b
+	})
+}
+
+// sqlToken is a token of SQL source; upper is its text upper-cased for
+// keyword matching, doc the comment lines directly above it
+type sqlToken struct {
+	text   string
+	upper  string
+	pos    int
+	end    int
+	quoted bool // A quoted identifier or string literal
+	doc    string
+}
+
+// upMigration blanks out the down sections of a migration (goose,
+// sql-migrate and dbmate annotations) and rewrites MySQL DELIMITER
+// changes back to semicolons, keeping offsets intact
+func upMigration(src string) string {
+	lines := strings.SplitAfter(src, "\n")
+	up := true
+	delimiter := ";"
+	blank := func(line string) string {
+		text := strings.TrimRight(line, "\r\n")
+		return strings.Repeat(" ", len(text)) + line[len(text):]
+	}
+	for i, line := range lines {
+		text := strings.ToLower(strings.TrimSpace(line))
+		end := len(strings.TrimRight(line, " \t\r\n"))
+		switch {
+		case strings.HasPrefix(text, "--"):
+			directive := strings.Join(strings.Fields(strings.TrimPrefix(text, "--")), " ")
+			switch directive {
+			case "+goose down", "+migrate down", "migrate:down":
+				up = false
+			case "+goose up", "+migrate up", "migrate:up":
+				up = true
+			}
+		case !up:
+			lines[i] = blank(line)
+		case strings.HasPrefix(text, "delimiter "):
+			delimiter = strings.TrimSpace(text[len("delimiter "):])
+			lines[i] = blank(line)
+		case delimiter != ";" && strings.HasSuffix(text, delimiter):
+			end -= len(delimiter)
+			lines[i] = line[:end] + ";" + strings.Repeat(" ", len(delimiter)-1) + line[end+len(delimiter):]
+		}
+	}
+	return strings.Join(lines, "")
+}
+
+// tokenizeSQL splits SQL source into tokens, dropping comments but keeping
+// the comment lines directly above a token on it
+func tokenizeSQL(src string) []sqlToken {
+	tokens := make([]sqlToken, 0, len(src)/4)
+	doc := make([]string, 0)
+	lastLine, line := -1, 0
+	for pos := 0; pos < len(src); {
+		c := src[pos]
+		start := pos
+		quoted := false
+		switch {
+		case c == '\n':
+			if pos > 0 && strings.TrimSpace(src[strings.LastIndexByte(src[:pos], '\n')+1:pos]) == "" {
+				doc = doc[:0] // A blank line detaches the comments above it
+			}
+			line++
+			pos++
+			continue
+		case c == ' ' || c == '\t' || c == '\r':
+			pos++
+			continue
+		case strings.HasPrefix(src[pos:], "--"):
+			end := strings.IndexByte(src[pos:], '\n')
+			if end < 0 {
+				end = len(src) - pos
+			}
+			text := strings.TrimSpace(src[pos+2 : pos+end])
+			if line != lastLine && !strings.HasPrefix(text, "+") && !strings.HasPrefix(text, "migrate:") {
+				doc = append(doc, text)
+			}
+			pos += end
+			continue
+		case strings.HasPrefix(src[pos:], "/*"):
+			end := strings.Index(src[pos+2:], "*/")
+			if end < 0 {
+				end = len(src) - pos - 4
+			}
+			comment := src[pos : pos+end+4]
+			if line != lastLine {
+				doc = append(doc, docCommentText("/**"+strings.TrimPrefix(comment[2:], "*")))
+			}
+			line += strings.Count(comment, "\n")
+			pos += end + 4
+			continue
+		case c == '\'' || c == '"' || c == '`':
+			// Doubled quotes escape themselves; backslashes escape in MySQL and E'' strings
+			pos++
+			for pos < len(src) {
+				if src[pos] == '\\' && c == '\'' {
+					pos += 2
+					continue
+				}
+				if src[pos] == c {
+					if pos+1 < len(src) && src[pos+1] == c {
+						pos += 2
+						continue
+					}
+					break
+				}
+				pos++
+			}
+			pos++
+			quoted = true
+		case c == '$' && sqlDollarQuote(src[pos:]) != "":
+			tag := sqlDollarQuote(src[pos:])
+			end := strings.Index(src[pos+len(tag):], tag)
+			if end < 0 {
+				pos = len(src)
+			} else {
+				pos += len(tag) + end + len(tag)
+			}
+			quoted = true
+		case c == '_' || isIdentByte(c) || c >= 0x80:
+			for pos < len(src) && (isIdentByte(src[pos]) || src[pos] >= 0x80) {
+				pos++
+			}
+		case strings.HasPrefix(src[pos:], "::"):
+			pos += 2
+		default:
+			pos++
+		}
+		if pos > len(src) {
+			pos = len(src)
+		}
+		line += strings.Count(src[start:pos], "\n")
+		text := src[start:pos]
+		tokens = append(tokens, sqlToken{text: text, upper: strings.ToUpper(text), pos: start, end: pos, quoted: quoted, doc: strings.Join(doc, "\n")})
+		doc = doc[:0]
+		lastLine = line
+	}
+	return tokens
+}
+
+// sqlDollarQuote returns the opening $tag$ of a PostgreSQL dollar-quoted
+// string s starts with, or "" ($1 is a parameter)
+func sqlDollarQuote(s string) string {
+	i := 1
+	for i < len(s) && (isIdentByte(s[i]) && s[i] != '$') {
+		i++
+	}
+	if i >= len(s) || s[i] != '$' || i > 1 && isDigit(s[1]) {
+		return ""
+	}
+	return s[:i+1]
+}
+
+// sqlSchema is the database schema built up by applying statements
+type sqlSchema struct {
+	objects map[string]*CodeElement // By lower-cased name: tables, views, indexes and functions share it
+	order   []*CodeElement          // Creation order, including dropped objects
+	altered map[*CodeElement]bool   // Tables whose columns no longer match their CREATE statement
+}
+
+// sqlStatement is the state of parsing one statement
+type sqlStatement struct {
+	tokens []sqlToken
+	i      int
+	src    string
+	path   string
+	lines  lineIndex
+}
+
+// apply applies the statements of a file to the schema
+func (s *sqlSchema) apply(path string, content []byte) {
+	src := upMigration(string(content))
+	tokens := tokenizeSQL(src)
+	lines := lineStarts(src)
+
+	// Statements end at semicolons outside BEGIN ... END blocks (MySQL routines)
+	start, depth := 0, 0
+	for i := 0; i <= len(tokens); i++ {
+		if i < len(tokens) {
+			tok := tokens[i]
+			next := ""
+			if i+1 < len(tokens) {
+				next = tokens[i+1].upper
+			}
+			switch {
+			case tok.quoted:
+			case tok.upper == "BEGIN" && next != ";" && next != "TRANSACTION" && next != "WORK" && next != "ISOLATION":
+				depth++
+			case tok.upper == "CASE":
+				depth++
+			case tok.upper == "END" && next != "IF" && next != "LOOP" && next != "WHILE" && next != "REPEAT" && next != "FOR":
+				if depth > 0 {
+					depth--
+				}
+			}
+			if tok.text != ";" || depth > 0 {
+				continue
+			}
+		}
+		if i > start {
+			st := &sqlStatement{tokens: tokens[start:i], src: src, path: path, lines: lines}
+			s.statement(st)
+		}
+		start = i + 1
+	}
+}
+
+// tok returns the token at i of the statement, or an empty one past its end
+func (st *sqlStatement) tok(i int) sqlToken {
+	if i >= len(st.tokens) {
+		return sqlToken{}
+	}
+	return st.tokens[i]
+}
+
+// peek returns the current token upper-cased
+func (st *sqlStatement) peek() string {
+	return st.tok(st.i).upper
+}
+
+// accept consumes the current token if it is one of the keywords
+func (st *sqlStatement) accept(keywords ...string) bool {
+	for _, kw := range keywords {
+		if st.i < len(st.tokens) && st.tokens[st.i].upper == kw && !st.tokens[st.i].quoted {
+			st.i++
+			return true
+		}
+	}
+	return false
+}
+
+// text returns the source of tokens start to end (exclusive)
+func (st *sqlStatement) text(start, end int) string {
+	if end > len(st.tokens) {
+		end = len(st.tokens)
+	}
+	if end <= start {
+		return ""
+	}
+	return st.src[st.tokens[start].pos:st.tokens[end-1].end]
+}
+
+// name parses a possibly qualified and quoted name (public."User")
+func (st *sqlStatement) name() string {
+	parts := make([]string, 0, 2)
+	for st.i < len(st.tokens) {
+		parts = append(parts, sqlIdent(st.tokens[st.i].text))
+		st.i++
+		if st.peek() != "." {
+			break
+		}
+		st.i++
+	}
+	return strings.Join(parts, ".")
+}
+
+// sqlIdent strips the quotes of a quoted identifier
+func sqlIdent(s string) string {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '`') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// skipParens skips a parenthesised token run starting at the current token
+func (st *sqlStatement) skipParens() {
+	depth := 0
+	for st.i < len(st.tokens) {
+		switch st.tokens[st.i].text {
+		case "(":
+			depth++
+		case ")":
+			depth--
+		}
+		st.i++
+		if depth <= 0 {
+			return
+		}
+	}
+}
+
+// list parses a parenthesised, comma-separated list into the token ranges of its items
+func (st *sqlStatement) list() [][2]int {
+	items := make([][2]int, 0)
+	if st.peek() != "(" {
+		return items
+	}
+	st.i++
+	start, depth := st.i, 0
+	for ; st.i < len(st.tokens); st.i++ {
+		switch st.tokens[st.i].text {
+		case "(":
+			depth++
+		case ")":
+			if depth == 0 {
+				if st.i > start {
+					items = append(items, [2]int{start, st.i})
+				}
+				st.i++
+				return items
+			}
+			depth--
+		case ",":
+			if depth == 0 {
+				items = append(items, [2]int{start, st.i})
+				start = st.i + 1
+			}
+		}
+	}
+	return items
+}
+
+// element creates an element for the statement
+func (st *sqlStatement) element(typ ElementType, name string) *CodeElement {
+	first, last := st.tokens[0], st.tokens[len(st.tokens)-1]
+	return &CodeElement{
+		Type:      typ,
+		Name:      name,
+		ID:        sqlID(st.path, name),
+		File:      st.path,
+		Line:      st.lines.line(first.pos),
+		EndLine:   st.lines.line(last.end - 1),
+		Body:      st.src[first.pos:last.end] + ";",
+		Docstring: first.doc,
+		Exports:   true,
+		Language:  "sql",
+		IndexedAt: time.Now(),
+	}
+}
+
+// sqlKey returns the key of an object name: names are case-insensitive,
+// and the default schema (public.users) is the same as none
+func sqlKey(name string) string {
+	name = strings.ToLower(name)
+	for _, schema := range []string{"public.", "dbo.", "main."} {
+		name = strings.TrimPrefix(name, schema)
+	}
+	return name
+}
+
+// add records a created object, replacing one of the same name
+func (s *sqlSchema) add(el *CodeElement) {
+	s.objects[sqlKey(el.Name)] = el
+	s.order = append(s.order, el)
+}
+
+// lookup returns the live object of a name and type, or nil
+func (s *sqlSchema) lookup(name string, types ...ElementType) *CodeElement {
+	el := s.objects[sqlKey(name)]
+	if el == nil {
+		return nil
+	}
+	for _, t := range types {
+		if el.Type == t {
+			return el
+		}
+	}
+	return nil
+}
+
+// statement applies one statement
+func (s *sqlSchema) statement(st *sqlStatement) {
+	switch {
+	case st.accept("CREATE"):
+		// Skip modifiers (OR REPLACE, TEMPORARY, UNIQUE, DEFINER = user) up to the kind of object
+		replace := false
+		for st.i < len(st.tokens) && !sqlObjectKinds[st.peek()] && st.peek() != "(" {
+			replace = replace || st.peek() == "REPLACE"
+			st.i++
+		}
+		switch {
+		case st.accept("TABLE"):
+			s.createTable(st)
+		case st.accept("VIEW"):
+			s.createView(st, replace)
+		case st.accept("INDEX"):
+			s.createIndex(st)
+		case st.accept("FUNCTION", "PROCEDURE"):
+			s.createFunction(st)
+		}
+	case st.accept("ALTER"):
+		switch {
+		case st.accept("TABLE"):
+			s.alterTable(st)
+		case st.accept("VIEW", "INDEX"):
+			st.accept("IF") // IF EXISTS
+			st.accept("EXISTS")
+			name := st.name()
+			if el := s.lookup(name, TypeView, TypeIndex); el != nil && st.accept("RENAME") && st.accept("TO") {
+				s.rename(el, st.name())
+			}
+		}
+	case st.accept("DROP"):
+		st.accept("MATERIALIZED")
+		if !st.accept("TABLE", "VIEW", "INDEX", "FUNCTION", "PROCEDURE") {
+			return
+		}
+		st.accept("CONCURRENTLY")
+		if st.accept("IF") {
+			st.accept("EXISTS")
+		}
+		for st.i < len(st.tokens) {
+			name := st.name()
+			if st.peek() == "(" { // Function signature
+				st.skipParens()
+			}
+			s.drop(name)
+			if !st.accept(",") {
+				break
+			}
+		}
+	case st.accept("RENAME"): // MySQL RENAME TABLE a TO b
+		if !st.accept("TABLE") {
+			return
+		}
+		for st.i < len(st.tokens) {
+			el := s.lookup(st.name(), TypeTable)
+			st.accept("TO")
+			name := st.name()
+			if el != nil {
+				s.rename(el, name)
+			}
+			if !st.accept(",") {
+				break
+			}
+		}
+	case st.accept("COMMENT"):
+		s.comment(st)
+	}
+}
+
+// sqlObjectKinds are the keywords naming what CREATE creates; only
+// tables, views, indexes and routines are indexed
+var sqlObjectKinds = map[string]bool{
+	"TABLE": true, "VIEW": true, "INDEX": true, "FUNCTION": true, "PROCEDURE": true,
+	"TRIGGER": true, "TYPE": true, "SEQUENCE": true, "SCHEMA": true, "EXTENSION": true,
+	"DOMAIN": true, "ROLE": true, "USER": true, "DATABASE": true, "POLICY": true,
+	"RULE": true, "PUBLICATION": true, "SUBSCRIPTION": true, "EVENT": true, "SERVER": true,
+	"AGGREGATE": true, "OPERATOR": true, "CAST": true, "COLLATION": true, "STATISTICS": true,
+	"TABLESPACE": true, "GROUP": true, "ON": true,
+}
+
+// drop removes an object and, for a table, its indexes
+func (s *sqlSchema) drop(name string) {
+	el := s.objects[sqlKey(name)]
+	if el == nil {
+		return
+	}
+	delete(s.objects, sqlKey(name))
+	if el.Type != TypeTable {
+		return
+	}
+	for key, obj := range s.objects {
+		if obj.Type == TypeIndex && obj.Context == el.ID {
+			delete(s.objects, key)
+		}
+	}
+}
+
+// rename gives an object a new name; indexes follow a renamed table
+func (s *sqlSchema) rename(el *CodeElement, name string) {
+	delete(s.objects, sqlKey(el.Name))
+	oldID := el.ID
+	el.Name = name
+	el.ID = sqlID(el.File, name)
+	s.objects[sqlKey(name)] = el
+	for _, obj := range s.objects {
+		if obj.Type == TypeIndex && obj.Context == oldID {
+			obj.Context = el.ID
+		}
+	}
+	if el.Type == TypeTable {
+		s.altered[el] = true
+	}
+}
+
+// createTable parses CREATE TABLE [IF NOT EXISTS] name (columns and constraints)
+func (s *sqlSchema) createTable(st *sqlStatement) {
+	ifNotExists := st.accept("IF") && st.accept("NOT") && st.accept("EXISTS")
+	name := st.name()
+	if ifNotExists && s.lookup(name, TypeTable) != nil {
+		return
+	}
+	el := st.element(TypeTable, name)
+	el.Fields = make([]Field, 0)
+	s.add(el)
+	for _, item := range st.list() {
+		s.tableItem(st, el, item[0], item[1])
+	}
+}
+
+// sqlColumnConstraints are the keywords that end the type of a column definition
+var sqlColumnConstraints = map[string]bool{
+	"CONSTRAINT": true, "NOT": true, "NULL": true, "DEFAULT": true, "PRIMARY": true,
+	"UNIQUE": true, "REFERENCES": true, "CHECK": true, "GENERATED": true, "COLLATE": true,
+	"AUTO_INCREMENT": true, "AUTOINCREMENT": true, "IDENTITY": true, "ON": true, "COMMENT": true,
+	"AS": true,
+}
+
+// tableItem applies a column definition or table constraint of CREATE TABLE
+// or ALTER TABLE ADD, spanning tokens start to end of the statement
+func (s *sqlSchema) tableItem(st *sqlStatement, table *CodeElement, start, end int) {
+	first := st.tok(start)
+	if !first.quoted {
+		switch first.upper {
+		case "CONSTRAINT", "PRIMARY", "UNIQUE", "FOREIGN", "CHECK", "EXCLUDE", "KEY", "INDEX", "FULLTEXT", "SPATIAL":
+			s.tableConstraint(st, table, start, end)
+			return
+		case "LIKE":
+			return
+		}
+	}
+
+	// MySQL places added columns with AFTER col or FIRST
+	if end-start > 2 && st.tok(end-2).upper == "AFTER" {
+		end -= 2
+	} else if end-start > 1 && st.tok(end-1).upper == "FIRST" {
+		end--
+	}
+	field := Field{Name: sqlIdent(first.text), Docstring: first.doc}
+	typeEnd := start + 1
+	for typeEnd < end && (st.tok(typeEnd).quoted || !sqlColumnConstraints[st.tok(typeEnd).upper]) {
+		typeEnd++
+	}
+	field.Type = st.text(start+1, typeEnd)
+	field.Tag = st.text(typeEnd, end)
+	for i := typeEnd; i+1 < end; i++ {
+		if st.tok(i).upper == "COMMENT" && st.tok(i+1).quoted { // MySQL column comment
+			field.Docstring = sqlString(st.tok(i + 1).text)
+		}
+	}
+
+	for i, existing := range table.Fields {
+		if strings.EqualFold(existing.Name, field.Name) {
+			table.Fields[i] = field
+			return
+		}
+	}
+	table.Fields = append(table.Fields, field)
+}
+
+// tableConstraint attaches a table constraint to the columns it names:
+// PRIMARY KEY (id) marks id, FOREIGN KEY (org_id) REFERENCES orgs (id)
+// gives org_id the reference. Named MySQL inline indexes (KEY idx (col))
+// become index elements.
+func (s *sqlSchema) tableConstraint(st *sqlStatement, table *CodeElement, start, end int) {
+	i := start
+	if st.tok(i).upper == "CONSTRAINT" {
+		i += 2
+	}
+	kind := st.tok(i).upper
+	body := i
+	for i < end && st.tok(i).text != "(" {
+		i++
+	}
+	if i >= end {
+		return
+	}
+	name := st.tok(i - 1)
+	named := i-1 > body && (name.quoted || !sqlIndexKeywords[name.upper])
+	sub := &sqlStatement{tokens: st.tokens[:end], i: i, src: st.src}
+	columns := make([]string, 0)
+	for _, item := range sub.list() {
+		columns = append(columns, sqlIdent(st.tok(item[0]).text))
+	}
+	rest := sub.i
+
+	switch kind {
+	case "KEY", "INDEX", "FULLTEXT", "SPATIAL", "UNIQUE":
+		if named {
+			idx := st.element(TypeIndex, sqlIdent(name.text))
+			idx.Line = st.lines.line(st.tok(start).pos)
+			idx.EndLine = st.lines.line(st.tok(end-1).end - 1)
+			idx.Body = st.text(start, end)
+			idx.Docstring = st.tok(start).doc
+			idx.Context = table.ID
+			idx.Fields = columnFields(columns)
+			s.add(idx)
+		}
+		if kind != "UNIQUE" {
+			return
+		}
+	case "CHECK", "EXCLUDE":
+		return
+	}
+
+	constraint := st.text(body, end)
+	if len(columns) == 1 {
+		switch kind {
+		case "PRIMARY":
+			constraint = "PRIMARY KEY"
+		case "UNIQUE":
+			constraint = "UNIQUE"
+		case "FOREIGN":
+			constraint = st.text(rest, end)
+		}
+	}
+	for _, column := range columns {
+		for j := range table.Fields {
+			if strings.EqualFold(table.Fields[j].Name, column) {
+				table.Fields[j].Tag = strings.TrimSpace(table.Fields[j].Tag + " " + constraint)
+			}
+		}
+	}
+}
+
+// sqlIndexKeywords are the keywords that can precede the column list of an unnamed index
+var sqlIndexKeywords = map[string]bool{
+	"KEY": true, "INDEX": true, "UNIQUE": true, "FULLTEXT": true, "SPATIAL": true, "PRIMARY": true,
+}
+
+// columnFields turns column names into fields
+func columnFields(columns []string) []Field {
+	fields := make([]Field, len(columns))
+	for i, column := range columns {
+		fields[i] = Field{Name: column}
+	}
+	return fields
+}
+
+// sqlString returns the contents of a string literal
+func sqlString(s string) string {
+	if len(s) >= 2 && s[0] == '\'' {
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'")
+	}
+	return s
+}
+
+// alterTable applies the comma-separated actions of ALTER TABLE
+func (s *sqlSchema) alterTable(st *sqlStatement) {
+	if st.accept("IF") {
+		st.accept("EXISTS")
+	}
+	st.accept("ONLY")
+	table := s.lookup(st.name(), TypeTable)
+	if table == nil {
+		return
+	}
+	s.altered[table] = true
+
+	for st.i < len(st.tokens) {
+		start := st.i
+		depth := 0
+		for ; st.i < len(st.tokens); st.i++ {
+			if text := st.tokens[st.i].text; text == "(" {
+				depth++
+			} else if text == ")" {
+				depth--
+			} else if text == "," && depth == 0 {
+				break
+			}
+		}
+		s.alterAction(st, table, start, st.i)
+		st.i++
+	}
+}
+
+// alterAction applies one action of ALTER TABLE spanning tokens start to end
+func (s *sqlSchema) alterAction(st *sqlStatement, table *CodeElement, start, end int) {
+	a := &sqlStatement{tokens: st.tokens[:end], i: start, src: st.src}
+	column := func() int {
+		for i, field := range table.Fields {
+			if strings.EqualFold(field.Name, sqlIdent(a.tok(a.i).text)) {
+				return i
+			}
+		}
+		return -1
+	}
+
+	switch {
+	case a.accept("ADD"):
+		a.accept("COLUMN")
+		if a.accept("IF") {
+			a.accept("NOT")
+			a.accept("EXISTS")
+		}
+		s.tableItem(st, table, a.i, end)
+	case a.accept("DROP"):
+		if a.accept("CONSTRAINT", "PRIMARY", "FOREIGN", "INDEX", "KEY") {
+			return
+		}
+		a.accept("COLUMN")
+		if a.accept("IF") {
+			a.accept("EXISTS")
+		}
+		if i := column(); i >= 0 {
+			table.Fields = append(table.Fields[:i], table.Fields[i+1:]...)
+		}
+	case a.accept("RENAME"):
+		if a.accept("TO") {
+			s.rename(table, a.name())
+			return
+		}
+		if a.accept("CONSTRAINT", "INDEX", "KEY") {
+			return
+		}
+		a.accept("COLUMN")
+		i := column()
+		a.i++
+		if a.accept("TO") && i >= 0 {
+			table.Fields[i].Name = sqlIdent(a.tok(a.i).text)
+		}
+	case a.accept("MODIFY"): // MySQL MODIFY [COLUMN] col definition
+		a.accept("COLUMN")
+		s.tableItem(st, table, a.i, end)
+	case a.accept("CHANGE"): // MySQL CHANGE [COLUMN] old new definition
+		a.accept("COLUMN")
+		if i := column(); i >= 0 {
+			table.Fields = append(table.Fields[:i], table.Fields[i+1:]...)
+		}
+		s.tableItem(st, table, a.i+1, end)
+	case a.accept("ALTER"):
+		a.accept("COLUMN")
+		i := column()
+		if i < 0 {
+			return
+		}
+		a.i++
+		field := &table.Fields[i]
+		switch {
+		case a.accept("TYPE"):
+			field.Type = sqlTypeUntil(a, end, "USING", "COLLATE")
+		case a.accept("SET"):
+			switch {
+			case a.accept("DATA"):
+				a.accept("TYPE")
+				field.Type = sqlTypeUntil(a, end, "USING", "COLLATE")
+			case a.accept("NOT"):
+				field.Tag = strings.TrimSpace(field.Tag + " NOT NULL")
+			case a.accept("DEFAULT"):
+				field.Tag = strings.TrimSpace(removeDefault(field.Tag) + " DEFAULT " + a.text(a.i, end))
+			}
+		case a.accept("DROP"):
+			switch {
+			case a.accept("NOT"):
+				field.Tag = strings.TrimSpace(strings.Replace(field.Tag, "NOT NULL", "", 1))
+			case a.accept("DEFAULT"):
+				field.Tag = strings.TrimSpace(removeDefault(field.Tag))
+			}
+		}
+	}
+}
+
+// sqlTypeUntil returns the type text from the current token up to one of the keywords or end
+func sqlTypeUntil(st *sqlStatement, end int, keywords ...string) string {
+	start := st.i
+	for st.i < end {
+		for _, kw := range keywords {
+			if st.peek() == kw {
+				return st.text(start, st.i)
+			}
+		}
+		st.i++
+	}
+	return st.text(start, end)
+}
+
+// sqlDefault matches a DEFAULT clause in column constraints, up to the next constraint keyword
+var sqlDefault = regexp.MustCompile(`(?i)\s*\bDEFAULT\s+(?:'(?:[^']|'')*'|\([^)]*\)|[^\s]+(?:\([^)]*\))?)`)
+
+// removeDefault removes the DEFAULT clause from column constraints
+func removeDefault(tag string) string {
+	return strings.TrimSpace(sqlDefault.ReplaceAllString(tag, ""))
+}
+
+// createView parses CREATE VIEW name [(columns)] AS query
+func (s *sqlSchema) createView(st *sqlStatement, replace bool) {
+	ifNotExists := st.accept("IF") && st.accept("NOT") && st.accept("EXISTS")
+	name := st.name()
+	if ifNotExists && s.lookup(name, TypeView) != nil {
+		return
+	}
+	el := st.element(TypeView, name)
+	if st.peek() == "(" {
+		columns := make([]string, 0)
+		for _, item := range st.list() {
+			columns = append(columns, sqlIdent(st.tok(item[0]).text))
+		}
+		el.Fields = columnFields(columns)
+	}
+	if old := s.lookup(name, TypeView); old != nil && replace && old.Docstring != "" && el.Docstring == "" {
+		el.Docstring = old.Docstring
+	}
+	s.add(el)
+}
+
+// createIndex parses CREATE INDEX [name] ON table [USING method] (columns)
+func (s *sqlSchema) createIndex(st *sqlStatement) {
+	st.accept("CONCURRENTLY")
+	ifNotExists := st.accept("IF") && st.accept("NOT") && st.accept("EXISTS")
+	name := ""
+	if st.peek() != "ON" {
+		name = st.name()
+	}
+	if !st.accept("ON") {
+		return
+	}
+	st.accept("ONLY")
+	table := st.name()
+	if st.accept("USING") {
+		st.i++
+	}
+	columns := make([]string, 0)
+	for _, item := range st.list() {
+		columns = append(columns, sqlIdent(strings.Fields(st.text(item[0], item[1]))[0]))
+	}
+	if name == "" { // PostgreSQL names it table_column_idx
+		name = table + "_" + strings.Join(columns, "_") + "_idx"
+	}
+	if ifNotExists && s.lookup(name, TypeIndex) != nil {
+		return
+	}
+
+	el := st.element(TypeIndex, name)
+	el.Context = sqlID(st.path, table)
+	if t := s.lookup(table, TypeTable); t != nil {
+		el.Context = t.ID
+	}
+	el.Fields = columnFields(columns)
+	s.add(el)
+}
+
+// sqlRoutineEnd are the keywords that end the RETURNS type of a function
+var sqlRoutineEnd = map[string]bool{
+	"LANGUAGE": true, "AS": true, "IMMUTABLE": true, "STABLE": true, "VOLATILE": true,
+	"STRICT": true, "SECURITY": true, "BEGIN": true, "RETURN": true, "CALLED": true,
+	"PARALLEL": true, "COST": true, "ROWS": true, "SET": true, "DETERMINISTIC": true,
+	"NOT": true, "READS": true, "MODIFIES": true, "NO": true, "CONTAINS": true,
+	"COMMENT": true, "LEAKPROOF": true, "WINDOW": true, "SUPPORT": true, "TRANSFORM": true,
+}
+
+// sqlArgModes are the argument modes of a function parameter
+var sqlArgModes = map[string]bool{"IN": true, "OUT": true, "INOUT": true, "VARIADIC": true}
+
+// sqlTypeNames are the types that start with a word a parameter name could be
+var sqlTypeNames = map[string]bool{
+	"DOUBLE": true, "CHARACTER": true, "TIMESTAMP": true, "TIME": true, "BIT": true,
+	"INTERVAL": true, "NATIONAL": true,
+}
+
+// createFunction parses CREATE FUNCTION name (params) [RETURNS type] ...
+func (s *sqlSchema) createFunction(st *sqlStatement) {
+	name := st.name()
+	el := st.element(TypeFunction, name)
+	el.Params = make([]Parameter, 0)
+	for _, item := range st.list() {
+		start, end := item[0], item[1]
+		if sqlArgModes[st.tok(start).upper] && end-start > 1 {
+			start++
+		}
+		param := Parameter{}
+		for i := start; i < end; i++ {
+			if tok := st.tok(i); tok.upper == "DEFAULT" || tok.text == "=" {
+				param.Default = st.text(i+1, end)
+				param.Optional = true
+				end = i
+				break
+			}
+		}
+		if end-start > 1 && !sqlTypeNames[st.tok(start).upper] && st.tok(start+1).text != "(" && st.tok(start+1).text != "[" {
+			param.Name = sqlIdent(st.tok(start).text)
+			start++
+		}
+		param.Type = st.text(start, end)
+		el.Params = append(el.Params, param)
+	}
+	if st.accept("RETURNS") {
+		start := st.i
+		for st.i < len(st.tokens) && (st.tok(st.i).quoted || !sqlRoutineEnd[st.peek()]) {
+			if st.peek() == "(" {
+				st.skipParens()
+				continue
+			}
+			st.i++
+		}
+		el.Returns = st.text(start, st.i)
+	}
+	if old := s.lookup(name, TypeFunction); old != nil && old.Docstring != "" && el.Docstring == "" {
+		el.Docstring = old.Docstring
+	}
+	s.add(el)
+}
+
+// comment applies COMMENT ON TABLE|VIEW|INDEX|FUNCTION|COLUMN name IS 'text'
+func (s *sqlSchema) comment(st *sqlStatement) {
+	if !st.accept("ON") {
+		return
+	}
+	st.accept("MATERIALIZED")
+	kind := st.peek()
+	st.i++
+	name := st.name()
+	if st.peek() == "(" {
+		st.skipParens()
+	}
+	if !st.accept("IS") {
+		return
+	}
+	text := sqlString(st.tok(st.i).text)
+
+	switch kind {
+	case "TABLE", "VIEW", "INDEX", "FUNCTION", "PROCEDURE":
+		if el := s.objects[sqlKey(name)]; el != nil {
+			el.Docstring = text
+		}
+	case "COLUMN":
+		dot := strings.LastIndex(name, ".")
+		if dot < 0 {
+			return
+		}
+		if table := s.lookup(name[:dot], TypeTable, TypeView); table != nil {
+			for i := range table.Fields {
+				if strings.EqualFold(table.Fields[i].Name, name[dot+1:]) {
+					table.Fields[i].Docstring = text
+				}
+			}
+		}
+	}
+}
+
+// elements returns the live objects of the schema in creation order. A
+// table changed by later migrations gets its current definition as body.
+func (s *sqlSchema) elements() []CodeElement {
+	elements := make([]CodeElement, 0, len(s.objects))
+	for _, el := range s.order {
+		if s.objects[sqlKey(el.Name)] != el {
+			continue // Dropped or replaced
+		}
+		if s.altered[el] {
+			var sb strings.Builder
+			sb.WriteString("CREATE TABLE " + el.Name + " (\n")
+			for i, field := range el.Fields {
+				sb.WriteString("    " + strings.TrimSpace(field.Name+" "+field.Type+" "+field.Tag))
+				if i < len(el.Fields)-1 {
+					sb.WriteString(",")
+				}
+				sb.WriteString("\n")
+			}
+			sb.WriteString(");")
+			el.Body = sb.String()
+		}
+		el.Hash = HashCode(el.ID + "\n" + el.Body)
+		elements = append(elements, *el)
+	}
+	return elements
+}
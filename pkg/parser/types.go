@@ -3,6 +3,10 @@ package parser
 import (
 	"crypto/sha256"
 	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -16,40 +20,143 @@ const (
 	TypeType      ElementType = "type"
 	TypeStruct    ElementType = "struct"
 	TypeVariable  ElementType = "variable"
+	TypeConstant  ElementType = "constant"
+	TypePackage   ElementType = "package"
+	TypeImpl      ElementType = "impl" // Rust impl block: Name is the type, Implements the trait
+
+	// Database schema objects, from .sql files
+	TypeTable ElementType = "table" // Fields are the columns, Tag their constraints
+	TypeView  ElementType = "view"
+	TypeIndex ElementType = "index" // Fields are the indexed columns, Context the table
+
+	// A heading and its text in Markdown documentation; Context is the enclosing section
+	TypeSection ElementType = "section"
+
+	// API specifications: an operation of an OpenAPI path, and a schema
+	// (OpenAPI component or JSON Schema) whose Fields are its properties
+	TypeEndpoint ElementType = "endpoint"
+	TypeSchema   ElementType = "schema"
+
+	// A Makefile rule: Body is the rule line and its recipe, Calls the
+	// targets of the same Makefile it needs or runs
+	TypeTarget ElementType = "target"
+
+	// Functions go test runs, from _test.go files
+	TypeTest      ElementType = "test"
+	TypeBenchmark ElementType = "benchmark"
+	TypeFuzz      ElementType = "fuzz"
+	TypeExample   ElementType = "example"
 )
 
+// IsFunction reports whether elements of this type are functions (including test functions)
+func (t ElementType) IsFunction() bool {
+	switch t {
+	case TypeFunction, TypeTest, TypeBenchmark, TypeFuzz, TypeExample:
+		return true
+	}
+	return false
+}
+
 // CodeElement represents a parsed code element
 type CodeElement struct {
-	Type       ElementType `json:"type"`
-	Name       string      `json:"name"`
-	File       string      `json:"file"`
-	Line       int         `json:"line"`
-	EndLine    int         `json:"endLine"`
-	Hash       string      `json:"hash"`
+	Type    ElementType `json:"type"`
+	Name    string      `json:"name"`
+	ID      string      `json:"id"` // Stable symbol ID: "path/to/pkg.(*Type).Method"
+	File    string      `json:"file"`
+	Line    int         `json:"line"`
+	EndLine int         `json:"endLine"`
+	Hash    string      `json:"hash"`
+
+	// Generic type parameters (Type holds the constraint)
+	TypeParams []Parameter `json:"typeParams,omitempty"`
 
 	// Function/Method specific
 	Params    []Parameter `json:"params,omitempty"`
 	Returns   string      `json:"returns,omitempty"`
 	Async     bool        `json:"async,omitempty"`
 	Generator bool        `json:"generator,omitempty"`
+	Calls     []string    `json:"calls,omitempty"`    // IDs of called functions
+	CalledBy  []string    `json:"calledBy,omitempty"` // IDs of indexed callers
+
+	Metrics *Metrics `json:"metrics,omitempty"`
+	Context string   `json:"context,omitempty"` // Enclosing function of a named function literal, table of an index, section of a subsection
+
+	// Decorators applied to a function or class, as written after the @
+	Decorators []string `json:"decorators,omitempty"`
+
+	// Declarations and what implements them: bodiless Go functions and their
+	// assembly, .proto definitions and the Go code generated from them, API
+	// endpoints and the Go handlers serving them
+	ImplementedBy []string `json:"implementedBy,omitempty"` // IDs of the assembly functions (or //go:linkname target), generated Go, or handlers
+	Declaration   string   `json:"declaration,omitempty"`   // ID of the Go declaration an assembly function implements, the proto definition, or the endpoint
+
+	// Test functions name the element they exercise; subjects list them back (by ID)
+	Subject  string   `json:"subject,omitempty"`
+	TestedBy []string `json:"testedBy,omitempty"`
+	Examples []string `json:"examples,omitempty"`
+
+	// Documentation sections mention code elements; the elements list them back (by ID)
+	Mentions     []string `json:"mentions,omitempty"`     // Code spans and file:line references, as written
+	Documents    []string `json:"documents,omitempty"`    // IDs of the mentioned elements
+	DocumentedIn []string `json:"documentedIn,omitempty"` // IDs of the sections mentioning the element
 
 	// Class/Struct specific
 	Methods    []string `json:"methods,omitempty"`
 	Extends    string   `json:"extends,omitempty"`
 	Implements []string `json:"implements,omitempty"`
-	Fields     []string `json:"fields,omitempty"`
+	Fields     []Field  `json:"fields,omitempty"`
+
+	// Constant/Variable specific
+	ValueType string `json:"valueType,omitempty"`
+	Value     string `json:"value,omitempty"`
+	EnumOf    string `json:"enumOf,omitempty"` // Named type whose enum block this constant belongs to
+
+	// Enum values declared for a named type (in declaration order)
+	EnumValues []string `json:"enumValues,omitempty"`
+
+	// Module path of the go.mod the element belongs to
+	Module string `json:"module,omitempty"`
+
+	// Go import path of the code generated from a .proto file (option go_package)
+	GoPackage string `json:"goPackage,omitempty"`
+
+	// API endpoint specific
+	Method      string `json:"method,omitempty"`      // HTTP method, upper case
+	Route       string `json:"route,omitempty"`       // Path the server routes, base path included ("/v1/users/{id}")
+	OperationID string `json:"operationId,omitempty"` // operationId of the OpenAPI operation
+
+	// Makefile target specific: the prerequisites of the rule, as written
+	Prerequisites []string `json:"prerequisites,omitempty"`
+
+	// Package specific
+	ImportPath      string   `json:"importPath,omitempty"`
+	Files           []string `json:"files,omitempty"`
+	ExportedSymbols int      `json:"exportedSymbols,omitempty"`
 
 	// Common
-	Body       string   `json:"body"`
-	Docstring  string   `json:"docstring,omitempty"`
-	Imports    []string `json:"imports,omitempty"`
-	Exports    bool     `json:"exports,omitempty"`
+	Body      string   `json:"body"`
+	Docstring string   `json:"docstring,omitempty"`
+	Imports   []string `json:"imports,omitempty"`
+	Exports   bool     `json:"exports,omitempty"`
+
+	// Build constraint of the file ("linux && amd64"), from //go:build and the file name
+	BuildConstraint string `json:"buildConstraint,omitempty"`
 
 	// Metadata
 	Language  string    `json:"language"`
 	IndexedAt time.Time `json:"indexedAt"`
 }
 
+// Metrics holds complexity and size metrics of a function
+type Metrics struct {
+	Cyclomatic int `json:"cyclomatic"`
+	Cognitive  int `json:"cognitive"`
+	MaxNesting int `json:"maxNesting"`
+	Statements int `json:"statements"`
+	Params     int `json:"params"`
+	Lines      int `json:"lines"`
+}
+
 // Parameter represents a function/method parameter
 type Parameter struct {
 	Name     string `json:"name"`
@@ -58,25 +165,141 @@ type Parameter struct {
 	Optional bool   `json:"optional,omitempty"`
 }
 
+// Field represents a struct field
+type Field struct {
+	Name      string            `json:"name"`
+	Type      string            `json:"type,omitempty"`
+	Tag       string            `json:"tag,omitempty"`
+	Tags      map[string]string `json:"tags,omitempty"`   // Tag parsed into key/value pairs; a proto field's options and oneof
+	Number    int               `json:"number,omitempty"` // Field number of a proto field
+	Embedded  bool              `json:"embedded,omitempty"`
+	Docstring string            `json:"docstring,omitempty"`
+}
+
+// String renders the field as it would be declared
+func (f Field) String() string {
+	s := strings.TrimSpace(f.Name + " " + f.Type)
+	if f.Embedded {
+		s = f.Type
+	}
+	if f.Number > 0 {
+		s += " = " + strconv.Itoa(f.Number)
+	}
+	if f.Tag != "" {
+		s += " `" + f.Tag + "`"
+	}
+	return s
+}
+
+// UnmarshalJSON also accepts the bare field names written by older indexes
+func (f *Field) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		*f = Field{Name: name}
+		return nil
+	}
+
+	type plain Field // Without the UnmarshalJSON method
+	return json.Unmarshal(data, (*plain)(f))
+}
+
+// ParseStructTag splits a struct tag in the conventional `key:"value" key2:"value2"`
+// format into its pairs. Malformed remainders are ignored.
+func ParseStructTag(tag string) map[string]string {
+	if tag == "" {
+		return nil
+	}
+
+	pairs := make(map[string]string)
+	for tag != "" {
+		tag = strings.TrimLeft(tag, " ")
+		if tag == "" {
+			break
+		}
+
+		// Key runs up to the colon; keys cannot contain spaces, quotes or colons
+		i := 0
+		for i < len(tag) && tag[i] > ' ' && tag[i] != ':' && tag[i] != '"' && tag[i] != 0x7f {
+			i++
+		}
+		if i == 0 || i+1 >= len(tag) || tag[i] != ':' || tag[i+1] != '"' {
+			break
+		}
+		key := tag[:i]
+		tag = tag[i+1:]
+
+		// Quoted value, honouring escaped quotes
+		i = 1
+		for i < len(tag) && tag[i] != '"' {
+			if tag[i] == '\\' {
+				i++
+			}
+			i++
+		}
+		if i >= len(tag) {
+			break
+		}
+		value, err := strconv.Unquote(tag[:i+1])
+		if err != nil {
+			break
+		}
+		pairs[key] = value
+		tag = tag[i+1:]
+	}
+
+	if len(pairs) == 0 {
+		return nil
+	}
+	return pairs
+}
+
 // ParseResult contains parsing results and errors
 type ParseResult struct {
 	Elements []CodeElement
 	Errors   []ParseError
+	Files    int // Files loaded by GoLoader.LoadDir, those outside its build target not counted
 }
 
 // ParseError represents a parsing error
 type ParseError struct {
+	File    string
 	Message string
 	Line    int
 	Column  int
 }
 
+// Error formats the error as file:line:column: message
+func (e ParseError) Error() string {
+	switch {
+	case e.Line > 0 && e.Column > 0:
+		return fmt.Sprintf("%s:%d:%d: %s", e.File, e.Line, e.Column, e.Message)
+	case e.Line > 0:
+		return fmt.Sprintf("%s:%d: %s", e.File, e.Line, e.Message)
+	case e.File != "":
+		return e.File + ": " + e.Message
+	}
+	return e.Message
+}
+
 // Parser interface for language-specific parsers
 type Parser interface {
 	Parse(filePath string, content []byte) (*ParseResult, error)
 	SupportsFile(filePath string) bool
 }
 
+// SourceFile is a file handed to a BatchParser
+type SourceFile struct {
+	Path    string
+	Content []byte
+}
+
+// BatchParser is a parser whose files are only meaningful together, such
+// as SQL migrations that build on each other. It gets all its files at once.
+type BatchParser interface {
+	Parser
+	ParseAll(files []SourceFile) (*ParseResult, error)
+}
+
 // HashCode generates a hash from code body
 func HashCode(body string) string {
 	hash := sha256.Sum256([]byte(body))
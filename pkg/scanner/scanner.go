@@ -19,10 +19,11 @@ type ScannedFile struct {
 
 // Scanner handles recursive directory traversal and file filtering
 type Scanner struct {
-	rootPath       string
+	rootPath        string
 	includePatterns []string
 	excludePatterns []string
-	followSymlinks bool
+	followSymlinks  bool
+	includeScripts  bool
 }
 
 // New creates a new Scanner instance
@@ -31,7 +32,8 @@ func New(rootPath string) *Scanner {
 		rootPath: rootPath,
 		includePatterns: []string{
 			"*.js", "*.ts", "*.jsx", "*.tsx",
-			"*.go", "*.py", "*.java",
+			"*.go", "*.s", "*.c", "*.py", "*.java", "*.rs",
+			"*.sh", "*.bash", "*.mk", "Makefile", "makefile", "GNUmakefile",
 		},
 		excludePatterns: []string{
 			"node_modules", ".git", "dist", "build",
@@ -47,6 +49,12 @@ func (s *Scanner) SetIncludePatterns(patterns []string) {
 	s.includePatterns = patterns
 }
 
+// SetIncludeScripts makes the scanner include files without an extension,
+// which scripts often are; their shebang tells what they are
+func (s *Scanner) SetIncludeScripts(include bool) {
+	s.includeScripts = include
+}
+
 // SetExcludePatterns sets the directory/file patterns to exclude
 func (s *Scanner) SetExcludePatterns(patterns []string) {
 	s.excludePatterns = patterns
@@ -112,6 +120,12 @@ func (s *Scanner) Scan() ([]ScannedFile, error) {
 	return files, err
 }
 
+// Excludes reports whether a path relative to the root is left out of the
+// scan, by the exclude patterns or .gitignore
+func (s *Scanner) Excludes(relPath string) bool {
+	return s.shouldExclude(relPath)
+}
+
 // shouldExclude checks if the path matches exclude patterns
 func (s *Scanner) shouldExclude(relPath string) bool {
 	for _, pattern := range s.excludePatterns {
@@ -127,6 +141,9 @@ func (s *Scanner) shouldInclude(path string) bool {
 	if len(s.includePatterns) == 0 {
 		return true
 	}
+	if s.includeScripts && filepath.Ext(path) == "" {
+		return true
+	}
 
 	for _, pattern := range s.includePatterns {
 		matched, _ := filepath.Match(pattern, filepath.Base(path))
@@ -139,9 +156,9 @@ func (s *Scanner) shouldInclude(path string) bool {
 
 // Stats returns statistics about the scan
 type Stats struct {
-	TotalFiles   int
-	ByExtension  map[string]int
-	TotalSize    int64
+	TotalFiles  int
+	ByExtension map[string]int
+	TotalSize   int64
 }
 
 // GetStats performs a scan and returns statistics